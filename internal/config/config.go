@@ -1,44 +1,109 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/BurntSushi/toml"
+	"slices"
+	"sort"
 
 	"github.com/atkntepe/tyle/internal/layout"
 )
 
 type Config struct {
+	Version       int                `toml:"version"`
+	ActiveProfile string             `toml:"active_profile,omitempty"`
+	Settings      Settings           `toml:"settings"`
+	Theme         Theme              `toml:"theme"`
+	Keys          map[string]KeyList `toml:"keys,omitempty"`
+	CustomLayouts []CustomLayout     `toml:"custom_layouts"`
+	Workspaces    []Workspace        `toml:"workspaces,omitempty"`
+	Profiles      map[string]Profile `toml:"profiles,omitempty"`
+
+	origins  map[string]Origin
+	defined  map[string]bool
+	profile  string
+	warnings []error
+}
+
+type Profile struct {
 	Settings      Settings       `toml:"settings"`
-	CustomLayouts []CustomLayout `toml:"custom_layouts"`
+	CustomLayouts []CustomLayout `toml:"custom_layouts,omitempty"`
 }
 
 type Settings struct {
 	DelayBetweenSplitsMs int      `toml:"delay_between_splits_ms"`
 	AutoEqualize         bool     `toml:"auto_equalize"`
 	PickerColumns        int      `toml:"picker_columns"`
+	PickerSort           string   `toml:"picker_sort,omitempty"`
 	GhosttyConfigPath    string   `toml:"ghostty_config_path,omitempty"`
 	HiddenLayouts        []string `toml:"hidden_layouts,omitempty"`
+	LayoutOrder          []string `toml:"layout_order,omitempty"`
+}
+
+// Theme controls the picker's colours. Name picks a built-in palette
+// ("dark", "light" or "ghostty", which reads the colours from the Ghostty
+// config); the other fields override single colours with an ANSI number or
+// a #rrggbb value. Border is one of rounded, normal, thick, double or hidden.
+type Theme struct {
+	Name     string `toml:"name,omitempty"`
+	Card     string `toml:"card,omitempty"`
+	Selected string `toml:"selected,omitempty"`
+	Preview  string `toml:"preview,omitempty"`
+	Header   string `toml:"header,omitempty"`
+	Help     string `toml:"help,omitempty"`
+	Border   string `toml:"border,omitempty"`
+}
+
+// KeyList is the keys bound to one picker action. In TOML it can be a
+// single key or an array of keys.
+type KeyList []string
+
+func (k *KeyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*k = KeyList{v}
+	case []any:
+		keys := make(KeyList, 0, len(v))
+		for _, item := range v {
+			key, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("keys must be strings, got %v", item)
+			}
+			keys = append(keys, key)
+		}
+		*k = keys
+	default:
+		return fmt.Errorf("expected a key or an array of keys, got %v", v)
+	}
+	return nil
 }
 
 type CustomLayout struct {
 	ID          string             `toml:"id"`
 	Name        string             `toml:"name"`
 	Description string             `toml:"description"`
-	Preview     []string           `toml:"preview"`
-	PaneCount   int                `toml:"pane_count"`
+	Preview     []string           `toml:"preview,omitempty"`
+	PaneCount   int                `toml:"pane_count,omitzero"`
 	Steps       []CustomLayoutStep `toml:"steps"`
+
+	Equalize             *bool  `toml:"equalize,omitempty"`
+	DelayBetweenSplitsMs int    `toml:"delay_between_splits_ms,omitzero"`
+	FinalFocus           string `toml:"final_focus,omitempty"`
 }
 
 type CustomLayoutStep struct {
 	Action    string `toml:"action"`
 	Direction string `toml:"direction,omitempty"`
-	DelayMs   int    `toml:"delay_ms,omitempty"`
+	DelayMs   int    `toml:"delay_ms,omitzero"`
+	Amount    int    `toml:"amount,omitzero"`
+	Ghostty   string `toml:"ghostty,omitempty"`
+	Text      string `toml:"text,omitempty"`
 }
 
 func DefaultConfig() Config {
 	return Config{
+		Version: CurrentVersion,
 		Settings: Settings{
 			DelayBetweenSplitsMs: 200,
 			AutoEqualize:         true,
@@ -52,19 +117,22 @@ func ConfigPath() string {
 	return filepath.Join(home, ".config", "tyle", "config.toml")
 }
 
+// Load merges the system config, the user config, the nearest project
+// .tyle.toml and the active profile, in that order.
 func Load() Config {
-	cfg := DefaultConfig()
-
-	path := ConfigPath()
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return cfg
-	}
+	return LoadProfile("")
+}
 
-	if _, err := toml.DecodeFile(path, &cfg); err != nil {
-		return DefaultConfig()
+// LoadProfile is Load with an explicit profile. An empty name falls back to
+// TYLE_PROFILE and then to active_profile from the config files. A profile
+// named by either of those that no layer defines is reported in Warnings;
+// callers passing a name check it themselves with HasProfile.
+func LoadProfile(name string) Config {
+	from := ""
+	if name == "" {
+		name, from = os.Getenv("TYLE_PROFILE"), "TYLE_PROFILE"
 	}
-
-	return cfg
+	return loadLayered(name, from)
 }
 
 func Save(cfg Config) error {
@@ -73,13 +141,12 @@ func Save(cfg Config) error {
 		return err
 	}
 
-	f, err := os.Create(path)
+	data, err := encodeUser(cfg)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	return toml.NewEncoder(f).Encode(cfg)
+	return os.WriteFile(path, data, 0644)
 }
 
 func FromLayout(l layout.Layout) CustomLayout {
@@ -89,6 +156,9 @@ func FromLayout(l layout.Layout) CustomLayout {
 			Action:    string(s.Action),
 			Direction: string(s.Direction),
 			DelayMs:   s.DelayMs,
+			Amount:    s.Amount,
+			Ghostty:   s.Ghostty,
+			Text:      s.Text,
 		})
 	}
 	return CustomLayout{
@@ -98,6 +168,10 @@ func FromLayout(l layout.Layout) CustomLayout {
 		Preview:     l.Preview,
 		PaneCount:   l.PaneCount,
 		Steps:       steps,
+
+		Equalize:             l.Equalize,
+		DelayBetweenSplitsMs: l.SplitDelayMs,
+		FinalFocus:           string(l.FinalFocus),
 	}
 }
 
@@ -111,6 +185,172 @@ func (c *Config) AddLayout(cl CustomLayout) {
 	c.CustomLayouts = append(c.CustomLayouts, cl)
 }
 
+// CreateLayout adds a new custom layout. Unlike AddLayout it refuses an ID
+// that a preset uses, and one that a custom layout already has unless
+// replace is set.
+func (c *Config) CreateLayout(cl CustomLayout, replace bool) error {
+	if cl.ID == "" {
+		return fmt.Errorf("name must contain letters or digits")
+	}
+	if IsPreset(cl.ID) {
+		return fmt.Errorf("'%s' is a preset — pick another name", cl.ID)
+	}
+	if c.HasLayout(cl.ID) && !replace {
+		return fmt.Errorf("a layout with ID '%s' already exists — use --force to replace it", cl.ID)
+	}
+	c.AddLayout(cl)
+	return nil
+}
+
+// ReplaceLayout swaps the custom layout with ID oldID for cl, keeping its
+// position. If cl has a new ID, references in hidden_layouts, layout_order
+// and workspace tabs follow it. Without an oldID layout, cl is created.
+func (c *Config) ReplaceLayout(oldID string, cl CustomLayout) error {
+	if !c.HasLayout(oldID) {
+		return c.CreateLayout(cl, false)
+	}
+	if cl.ID != oldID {
+		if cl.ID == "" {
+			return fmt.Errorf("name must contain letters or digits")
+		}
+		if c.HasLayout(cl.ID) || IsPreset(cl.ID) {
+			return fmt.Errorf("a layout with ID '%s' already exists", cl.ID)
+		}
+	}
+
+	for i, existing := range c.CustomLayouts {
+		if existing.ID == oldID {
+			c.CustomLayouts[i] = cl
+		}
+	}
+	if cl.ID != oldID {
+		c.moveReferences(oldID, cl.ID)
+	}
+	return nil
+}
+
+func (c Config) HasLayout(id string) bool {
+	for _, cl := range c.CustomLayouts {
+		if cl.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveLayout deletes a custom layout and drops it from hidden_layouts and
+// layout_order, including in profiles. It reports whether the layout existed.
+func (c *Config) RemoveLayout(id string) bool {
+	found := false
+	var kept []CustomLayout
+	for _, cl := range c.CustomLayouts {
+		if cl.ID == id {
+			found = true
+			continue
+		}
+		kept = append(kept, cl)
+	}
+	if !found {
+		return false
+	}
+	c.CustomLayouts = kept
+
+	c.eachSettings(func(s *Settings) {
+		s.HiddenLayouts = replaceID(s.HiddenLayouts, id, "")
+		s.LayoutOrder = replaceID(s.LayoutOrder, id, "")
+	})
+	return true
+}
+
+// RenameLayout gives a custom layout a new name and the ID derived from it,
+// updating references in hidden_layouts, layout_order and workspace tabs.
+func (c *Config) RenameLayout(id, name string) (CustomLayout, error) {
+	newID := layout.Slugify(name)
+	if newID == "" {
+		return CustomLayout{}, fmt.Errorf("name must contain letters or digits")
+	}
+
+	idx := -1
+	for i, cl := range c.CustomLayouts {
+		if cl.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return CustomLayout{}, fmt.Errorf("custom layout '%s' not found", id)
+	}
+	if newID != id && (c.HasLayout(newID) || IsPreset(newID)) {
+		return CustomLayout{}, fmt.Errorf("a layout with ID '%s' already exists", newID)
+	}
+
+	c.CustomLayouts[idx].ID = newID
+	c.CustomLayouts[idx].Name = name
+	c.moveReferences(id, newID)
+	return c.CustomLayouts[idx], nil
+}
+
+// moveReferences points hidden_layouts, layout_order and workspace tabs
+// that name the layout oldID at newID instead.
+func (c *Config) moveReferences(oldID, newID string) {
+	c.eachSettings(func(s *Settings) {
+		s.HiddenLayouts = replaceID(s.HiddenLayouts, oldID, newID)
+		s.LayoutOrder = replaceID(s.LayoutOrder, oldID, newID)
+	})
+	for _, w := range c.Workspaces {
+		for i := range w.Tabs {
+			if w.Tabs[i].Layout == oldID {
+				w.Tabs[i].Layout = newID
+			}
+		}
+	}
+}
+
+func IsPreset(id string) bool {
+	for _, p := range layout.Presets() {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateSettings edits the top-level settings, or those of the named
+// profile, creating the profile if needed.
+func (c *Config) UpdateSettings(profile string, fn func(*Settings)) {
+	if profile == "" {
+		fn(&c.Settings)
+		return
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	p := c.Profiles[profile]
+	fn(&p.Settings)
+	c.Profiles[profile] = p
+}
+
+func (c *Config) eachSettings(fn func(*Settings)) {
+	fn(&c.Settings)
+	for name := range c.Profiles {
+		c.UpdateSettings(name, fn)
+	}
+}
+
+func replaceID(ids []string, old, new string) []string {
+	var out []string
+	for _, id := range ids {
+		if id == old {
+			if new == "" {
+				continue
+			}
+			id = new
+		}
+		out = append(out, id)
+	}
+	return out
+}
+
 func (c *Config) HideLayout(id string) {
 	for _, h := range c.Settings.HiddenLayouts {
 		if h == id {
@@ -139,6 +379,68 @@ func (c Config) IsHidden(id string) bool {
 	return false
 }
 
+// MinimalOrder returns the shortest layout_order that arranges base as ids:
+// the layouts that moved, leaving out the tail that already follows base.
+func MinimalOrder(ids, base []string) []string {
+	for k := 0; k < len(ids); k++ {
+		listed := map[string]bool{}
+		for _, id := range ids[:k] {
+			listed[id] = true
+		}
+		var rest []string
+		for _, id := range base {
+			if !listed[id] {
+				rest = append(rest, id)
+			}
+		}
+		if slices.Equal(rest, ids[k:]) {
+			return ids[:k]
+		}
+	}
+	return ids
+}
+
+func (c Config) HasProfile(name string) bool {
+	_, ok := c.Profiles[name]
+	return ok
+}
+
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OrderLayouts moves the layouts named in layout_order to the front, in that
+// order, and keeps the rest in their original order.
+func (c Config) OrderLayouts(layouts []layout.Layout) []layout.Layout {
+	if len(c.Settings.LayoutOrder) == 0 {
+		return layouts
+	}
+
+	rank := make(map[string]int, len(c.Settings.LayoutOrder))
+	for i, id := range c.Settings.LayoutOrder {
+		if _, ok := rank[id]; !ok {
+			rank[id] = i
+		}
+	}
+
+	ordered := make([]layout.Layout, len(layouts))
+	copy(ordered, layouts)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, iok := rank[ordered[i].ID]
+		rj, jok := rank[ordered[j].ID]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+	return ordered
+}
+
 func (c Config) ToLayouts() []layout.Layout {
 	var layouts []layout.Layout
 	for _, cl := range c.CustomLayouts {
@@ -148,6 +450,9 @@ func (c Config) ToLayouts() []layout.Layout {
 				Action:    layout.StepAction(s.Action),
 				Direction: layout.Direction(s.Direction),
 				DelayMs:   s.DelayMs,
+				Amount:    s.Amount,
+				Ghostty:   s.Ghostty,
+				Text:      s.Text,
 			})
 		}
 		layouts = append(layouts, layout.Layout{
@@ -157,6 +462,10 @@ func (c Config) ToLayouts() []layout.Layout {
 			Preview:     cl.Preview,
 			PaneCount:   cl.PaneCount,
 			Steps:       steps,
+
+			FinalFocus:   layout.Direction(cl.FinalFocus),
+			Equalize:     cl.Equalize,
+			SplitDelayMs: cl.DelayBetweenSplitsMs,
 		})
 	}
 	return layouts
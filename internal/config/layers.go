@@ -0,0 +1,434 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceSystem  Source = "system"
+	SourceUser    Source = "user"
+	SourceProject Source = "project"
+	SourceProfile Source = "profile"
+)
+
+const ProjectConfigName = ".tyle.toml"
+
+type Origin struct {
+	Source Source
+	Path   string
+}
+
+func (o Origin) String() string {
+	if o.Source == "" {
+		return string(SourceDefault)
+	}
+	if o.Path == "" {
+		return string(o.Source)
+	}
+	return fmt.Sprintf("%s (%s)", o.Source, o.Path)
+}
+
+type Setting struct {
+	Key    string
+	Value  any
+	Origin Origin
+}
+
+type layer struct {
+	origin  Origin
+	cfg     Config
+	meta    toml.MetaData
+	profile []string
+}
+
+func SystemConfigPath() string {
+	return filepath.Join("/etc", "tyle", "config.toml")
+}
+
+// ProjectConfigPath walks up from the working directory looking for a
+// .tyle.toml, stopping at the home directory.
+func ProjectConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if dir == home || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readLayer reads one config file. A missing file is not an error; one
+// that can't be parsed is, and the layer is left out.
+func readLayer(source Source, path string) (layer, bool, error) {
+	if path == "" {
+		return layer{}, false, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return layer{}, false, nil
+	}
+
+	var cfg Config
+	meta, err := decodeFile(path, &cfg)
+	if err != nil {
+		return layer{}, false, fmt.Errorf("ignoring %s config %s: %w", source, path, err)
+	}
+
+	return layer{origin: Origin{Source: source, Path: path}, cfg: cfg, meta: meta}, true, nil
+}
+
+// readLayers reads the system, user and project configs, returning the
+// errors of any that couldn't be read alongside the rest.
+func readLayers() ([]layer, []error) {
+	var layers []layer
+	var errs []error
+	for _, f := range []struct {
+		source Source
+		path   string
+	}{
+		{SourceSystem, SystemConfigPath()},
+		{SourceUser, ConfigPath()},
+		{SourceProject, ProjectConfigPath()},
+	} {
+		l, ok, err := readLayer(f.source, f.path)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if ok {
+			layers = append(layers, l)
+		}
+	}
+	return layers, errs
+}
+
+// loadLayered merges the layers and then the profile, from names where
+// the profile was set when it didn't come from the caller.
+func loadLayered(profile, from string) Config {
+	cfg := DefaultConfig()
+	cfg.origins = map[string]Origin{}
+
+	layers, errs := readLayers()
+	cfg.warnings = errs
+	for _, l := range layers {
+		cfg.merge(l)
+		if l.meta.IsDefined("active_profile") {
+			cfg.ActiveProfile = l.cfg.ActiveProfile
+		}
+	}
+	cfg.Profiles = mergeProfiles(layers)
+
+	if profile == "" {
+		profile, from = cfg.ActiveProfile, "active_profile"
+	}
+	if profile == "" {
+		return cfg
+	}
+	if !cfg.HasProfile(profile) {
+		if from != "" {
+			cfg.warnings = append(cfg.warnings,
+				fmt.Errorf("profile '%s' from %s not found — continuing without a profile", profile, from))
+		}
+		return cfg
+	}
+
+	for _, l := range layers {
+		if _, ok := l.cfg.Profiles[profile]; ok {
+			cfg.merge(profileLayer(l, profile))
+		}
+	}
+	cfg.profile = profile
+
+	return cfg
+}
+
+// mergeProfiles combines the profiles of every layer, a later layer's
+// settings and layouts overriding an earlier one's under the same name.
+func mergeProfiles(layers []layer) map[string]Profile {
+	merged := map[string]*Config{}
+	for _, l := range layers {
+		for name := range l.cfg.Profiles {
+			if merged[name] == nil {
+				merged[name] = &Config{origins: map[string]Origin{}}
+			}
+			merged[name].merge(profileLayer(l, name))
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	profiles := map[string]Profile{}
+	for name, c := range merged {
+		profiles[name] = Profile{Settings: c.Settings, CustomLayouts: c.CustomLayouts}
+	}
+	return profiles
+}
+
+func profileLayer(l layer, name string) layer {
+	p := l.cfg.Profiles[name]
+	return layer{
+		origin:  Origin{Source: SourceProfile, Path: l.origin.Path},
+		cfg:     Config{Settings: p.Settings, CustomLayouts: p.CustomLayouts},
+		meta:    l.meta,
+		profile: []string{"profiles", name},
+	}
+}
+
+func (c *Config) merge(l layer) {
+	c.mergeSection(reflect.ValueOf(&c.Settings).Elem(), reflect.ValueOf(l.cfg.Settings), "settings", l)
+	if l.profile == nil {
+		c.mergeSection(reflect.ValueOf(&c.Theme).Elem(), reflect.ValueOf(l.cfg.Theme), "theme", l)
+		for action, keys := range l.cfg.Keys {
+			if c.Keys == nil {
+				c.Keys = map[string]KeyList{}
+			}
+			c.Keys[action] = keys
+			c.origins["keys."+action] = l.origin
+		}
+	}
+
+	for _, cl := range l.cfg.CustomLayouts {
+		c.AddLayout(cl)
+		c.origins["layouts."+cl.ID] = l.origin
+	}
+
+	for _, w := range l.cfg.Workspaces {
+		c.AddWorkspace(w)
+		c.origins["workspaces."+w.ID] = l.origin
+	}
+}
+
+// mergeSection copies the fields of src that the layer defined under
+// section into dst. hidden_layouts is the exception: it adds up, so a
+// layout hidden by any layer stays hidden and each file lists only its own.
+// A profile's list replaces the one it inherits instead, so that a profile
+// can show layouts the base settings hide; the profile's lists from each
+// file still add up.
+func (c *Config) mergeSection(dst, src reflect.Value, section string, l layer) {
+	for i := 0; i < dst.NumField(); i++ {
+		key := tomlKey(dst.Type().Field(i))
+		path := append(append([]string{}, l.profile...), section, key)
+		if !l.meta.IsDefined(path...) {
+			continue
+		}
+		if section == "settings" && key == "hidden_layouts" {
+			if l.profile != nil && c.origins[section+"."+key].Source != SourceProfile {
+				c.clearHidden()
+			}
+			for _, id := range l.cfg.Settings.HiddenLayouts {
+				c.HideLayout(id)
+				c.origins["hidden."+id] = l.origin
+			}
+		} else {
+			dst.Field(i).Set(src.Field(i))
+		}
+		c.origins[section+"."+key] = l.origin
+	}
+}
+
+// clearHidden empties the hidden list along with where each entry came from.
+func (c *Config) clearHidden() {
+	c.Settings.HiddenLayouts = nil
+	for key := range c.origins {
+		if strings.HasPrefix(key, "hidden.") {
+			delete(c.origins, key)
+		}
+	}
+}
+
+func tomlKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+func (c Config) SettingList() []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(c.Settings)
+	for i := 0; i < v.NumField(); i++ {
+		key := tomlKey(v.Type().Field(i))
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  v.Field(i).Interface(),
+			Origin: c.origins["settings."+key],
+		})
+	}
+
+	v = reflect.ValueOf(c.Theme)
+	for i := 0; i < v.NumField(); i++ {
+		key := tomlKey(v.Type().Field(i))
+		settings = append(settings, Setting{
+			Key:    "theme." + key,
+			Value:  v.Field(i).Interface(),
+			Origin: c.origins["theme."+key],
+		})
+	}
+
+	actions := make([]string, 0, len(c.Keys))
+	for action := range c.Keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		settings = append(settings, Setting{
+			Key:    "keys." + action,
+			Value:  []string(c.Keys[action]),
+			Origin: c.origins["keys."+action],
+		})
+	}
+	return settings
+}
+
+func (c Config) LayoutOrigin(id string) Origin {
+	return c.origins["layouts."+id]
+}
+
+// HiddenOrigin returns the last file whose hidden_layouts lists id.
+func (c Config) HiddenOrigin(id string) Origin {
+	return c.origins["hidden."+id]
+}
+
+// ProfileHides reports whether the active profile sets hidden_layouts,
+// replacing the list the base settings hide.
+func (c Config) ProfileHides() bool {
+	return c.origins["settings.hidden_layouts"].Source == SourceProfile
+}
+
+func (c Config) WorkspaceOrigin(id string) Origin {
+	return c.origins["workspaces."+id]
+}
+
+// LoadUser reads only the user config file, which is the one Save writes.
+// Commands that modify the config should start from this rather than from
+// the merged result of Load. A file that can't be read is an error rather
+// than an empty config, so that saving never replaces it with defaults.
+func LoadUser() (Config, error) {
+	cfg := DefaultConfig()
+
+	path := ConfigPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+
+	meta, err := decodeFile(path, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("refusing to change %s: %w", path, err)
+	}
+	cfg.defined = definedKeys(meta)
+
+	return cfg, nil
+}
+
+func definedKeys(meta toml.MetaData) map[string]bool {
+	defined := map[string]bool{}
+	for _, k := range meta.Keys() {
+		defined[k.String()] = true
+	}
+	return defined
+}
+
+type userFile struct {
+	Version       int                    `toml:"version"`
+	ActiveProfile string                 `toml:"active_profile,omitempty"`
+	Settings      map[string]any         `toml:"settings,omitempty"`
+	Theme         map[string]any         `toml:"theme,omitempty"`
+	Keys          map[string]KeyList     `toml:"keys,omitempty"`
+	CustomLayouts []CustomLayout         `toml:"custom_layouts,omitempty"`
+	Workspaces    []Workspace            `toml:"workspaces,omitempty"`
+	Profiles      map[string]userProfile `toml:"profiles,omitempty"`
+}
+
+type userProfile struct {
+	Settings      map[string]any `toml:"settings,omitempty"`
+	CustomLayouts []CustomLayout `toml:"custom_layouts,omitempty"`
+}
+
+// encodeUser turns cfg into TOML, leaving out settings that the file never
+// set and that still hold their default value, so that they keep inheriting
+// from the system and project layers.
+func encodeUser(cfg Config) ([]byte, error) {
+	defaults := DefaultConfig().Settings
+	out := userFile{
+		Version:       CurrentVersion,
+		ActiveProfile: cfg.ActiveProfile,
+		Settings:      settingsMap(cfg.Settings, &defaults, cfg.defined, toml.Key{"settings"}),
+		Theme:         sectionMap(reflect.ValueOf(cfg.Theme), reflect.ValueOf(Theme{}), cfg.defined, toml.Key{"theme"}),
+		Keys:          cfg.Keys,
+		CustomLayouts: cfg.CustomLayouts,
+		Workspaces:    cfg.Workspaces,
+	}
+
+	for name, p := range cfg.Profiles {
+		if out.Profiles == nil {
+			out.Profiles = map[string]userProfile{}
+		}
+		out.Profiles[name] = userProfile{
+			Settings:      settingsMap(p.Settings, nil, cfg.defined, toml.Key{"profiles", name, "settings"}),
+			CustomLayouts: p.CustomLayouts,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// settingsMap keeps a setting if the file defined it or if it differs from
+// base. A nil base means the zero value.
+func settingsMap(s Settings, base *Settings, defined map[string]bool, prefix toml.Key) map[string]any {
+	if base == nil {
+		base = &Settings{}
+	}
+	return sectionMap(reflect.ValueOf(s), reflect.ValueOf(*base), defined, prefix)
+}
+
+func sectionMap(v, b reflect.Value, defined map[string]bool, prefix toml.Key) map[string]any {
+	m := map[string]any{}
+	for i := 0; i < v.NumField(); i++ {
+		key := tomlKey(v.Type().Field(i))
+		value := v.Field(i)
+		if !defined[append(append(toml.Key{}, prefix...), key).String()] &&
+			reflect.DeepEqual(value.Interface(), b.Field(i).Interface()) {
+			continue
+		}
+		if strings.Contains(v.Type().Field(i).Tag.Get("toml"), "omitempty") && value.IsZero() {
+			continue
+		}
+		m[key] = value.Interface()
+	}
+	return m
+}
+
+// Warnings returns the problems met while loading, such as a config file
+// that failed to parse and was left out.
+func (c Config) Warnings() []error {
+	return c.warnings
+}
+
+func (c Config) ProfileName() string {
+	return c.profile
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentVersion is the config schema version this build writes. Bump it
+// and append to migrations whenever the file format changes.
+const CurrentVersion = 1
+
+type Migration struct {
+	From    int
+	Summary string
+	Apply   func(raw map[string]any) error
+}
+
+var migrations = []Migration{
+	{
+		From:    0,
+		Summary: "add version key and drop delay_ms = 0 from non-delay steps",
+		Apply: func(raw map[string]any) error {
+			forEachLayoutTable(raw, func(cl map[string]any) {
+				steps, _ := cl["steps"].([]map[string]any)
+				for _, step := range steps {
+					if step["action"] != "delay" && isZeroInt(step["delay_ms"]) {
+						delete(step, "delay_ms")
+					}
+				}
+			})
+			return nil
+		},
+	},
+}
+
+type MigrationResult struct {
+	Path    string
+	From    int
+	Applied []Migration
+	Before  []byte
+	After   []byte
+	Backup  string
+}
+
+func (r MigrationResult) Changed() bool {
+	return len(r.Applied) > 0
+}
+
+func fileVersion(raw map[string]any) int {
+	v, ok := raw["version"].(int64)
+	if !ok {
+		return 0
+	}
+	return int(v)
+}
+
+// migrate upgrades raw in place to CurrentVersion and returns the
+// migrations it ran.
+func migrate(raw map[string]any) ([]Migration, error) {
+	version := fileVersion(raw)
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config version %d is newer than this tyle supports (%d) — upgrade tyle", version, CurrentVersion)
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		if err := m.Apply(raw); err != nil {
+			return applied, fmt.Errorf("migrating from version %d: %w", m.From, err)
+		}
+		version = m.From + 1
+		applied = append(applied, m)
+	}
+	raw["version"] = int64(version)
+
+	return applied, nil
+}
+
+// decodeFile reads a config file, migrating it in memory if it was written
+// by an older version.
+func decodeFile(path string, cfg *Config) (toml.MetaData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return toml.MetaData{}, err
+	}
+
+	var raw map[string]any
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return toml.MetaData{}, err
+	}
+
+	applied, err := migrate(raw)
+	if err != nil {
+		return toml.MetaData{}, err
+	}
+	if len(applied) == 0 {
+		return toml.Decode(string(data), cfg)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return toml.MetaData{}, err
+	}
+	return toml.Decode(buf.String(), cfg)
+}
+
+// MigrateFile upgrades the config file at path to CurrentVersion. With
+// write set, the original is kept next to it as <path>.v<N>.bak.
+func MigrateFile(path string, write bool) (MigrationResult, error) {
+	result := MigrationResult{Path: path}
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	result.Before = before
+	result.After = before
+
+	var raw map[string]any
+	if _, err := toml.Decode(string(before), &raw); err != nil {
+		return result, err
+	}
+	result.From = fileVersion(raw)
+
+	applied, err := migrate(raw)
+	if err != nil {
+		return result, err
+	}
+	result.Applied = applied
+	if len(applied) == 0 {
+		return result, nil
+	}
+
+	var cfg Config
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return result, err
+	}
+	meta, err := toml.Decode(buf.String(), &cfg)
+	if err != nil {
+		return result, err
+	}
+	cfg.defined = definedKeys(meta)
+
+	after, err := encodeUser(cfg)
+	if err != nil {
+		return result, err
+	}
+	result.After = after
+
+	if !write {
+		return result, nil
+	}
+
+	result.Backup = fmt.Sprintf("%s.v%d.bak", path, result.From)
+	if err := os.WriteFile(result.Backup, before, 0644); err != nil {
+		return result, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.WriteFile(path, after, 0644); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func forEachLayoutTable(raw map[string]any, fn func(map[string]any)) {
+	visit := func(table map[string]any) {
+		layouts, _ := table["custom_layouts"].([]map[string]any)
+		for _, cl := range layouts {
+			fn(cl)
+		}
+	}
+
+	visit(raw)
+	profiles, _ := raw["profiles"].(map[string]any)
+	for _, p := range profiles {
+		if pm, ok := p.(map[string]any); ok {
+			visit(pm)
+		}
+	}
+}
+
+func isZeroInt(v any) bool {
+	n, ok := v.(int64)
+	return ok && n == 0
+}
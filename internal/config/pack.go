@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// ShareCodePrefix starts every share code, so that a pasted code is easy
+// to recognise.
+const ShareCodePrefix = "tyle:"
+
+// pack is a file of layouts to move between machines. It uses the same
+// [[custom_layouts]] tables as the config file.
+type pack struct {
+	Version       int            `toml:"version"`
+	CustomLayouts []CustomLayout `toml:"custom_layouts"`
+}
+
+// EncodePack writes layouts as a TOML pack that DecodePack and the
+// [[custom_layouts]] section of a config file both read.
+func EncodePack(layouts []layout.Layout) ([]byte, error) {
+	p := pack{Version: CurrentVersion}
+	for _, l := range layouts {
+		p.CustomLayouts = append(p.CustomLayouts, FromLayout(l))
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodePack reads the layouts in a TOML pack, or in any file with
+// [[custom_layouts]] tables. Missing names, IDs and pane counts are filled
+// in from each other and from the steps.
+func DecodePack(data []byte) ([]layout.Layout, error) {
+	var p pack
+	if _, err := toml.Decode(string(data), &p); err != nil {
+		return nil, err
+	}
+	if len(p.CustomLayouts) == 0 {
+		return nil, fmt.Errorf("no [[custom_layouts]] found")
+	}
+
+	layouts := Config{CustomLayouts: p.CustomLayouts}.ToLayouts()
+	for i := range layouts {
+		l := &layouts[i]
+		if l.Name == "" {
+			l.Name = l.ID
+		}
+		if l.ID == "" {
+			l.ID = layout.Slugify(l.Name)
+		}
+		if l.PaneCount == 0 {
+			l.PaneCount = layout.CountPanes(l.Steps)
+		}
+	}
+	return layouts, nil
+}
+
+// ShareCode packs layouts into a single line that can be pasted into chat:
+// the TOML pack, deflated and base64url-encoded after ShareCodePrefix.
+func ShareCode(layouts []layout.Layout) (string, error) {
+	data, err := EncodePack(layouts)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return ShareCodePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeShareCode reads the layouts in a share code. The prefix is
+// optional, and whitespace from line-wrapped pastes is ignored.
+func DecodeShareCode(code string) ([]layout.Layout, error) {
+	code = strings.Join(strings.Fields(code), "")
+	code = strings.TrimPrefix(code, ShareCodePrefix)
+	code = strings.TrimRight(code, "=")
+
+	compressed, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid share code: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(compressed)), 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid share code: %w", err)
+	}
+	return DecodePack(data)
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// Workspace is a named set of tabs, each shaped by a layout, brought up
+// with `tyle up`.
+type Workspace struct {
+	ID   string         `toml:"id"`
+	Name string         `toml:"name,omitempty"`
+	Tabs []WorkspaceTab `toml:"tabs"`
+}
+
+// WorkspaceTab picks its layout by ID, or describes it inline with either
+// columns (rows per column, as in `tyle add`) or steps. A tab with none of
+// them stays a single pane.
+type WorkspaceTab struct {
+	Title    string             `toml:"title,omitempty"`
+	Layout   string             `toml:"layout,omitempty"`
+	Columns  []int              `toml:"columns,omitempty"`
+	Steps    []CustomLayoutStep `toml:"steps,omitempty"`
+	Commands []string           `toml:"commands,omitempty"`
+	Window   bool               `toml:"window,omitempty"`
+}
+
+func (c *Config) AddWorkspace(w Workspace) {
+	for i, existing := range c.Workspaces {
+		if existing.ID == w.ID {
+			c.Workspaces[i] = w
+			return
+		}
+	}
+	c.Workspaces = append(c.Workspaces, w)
+}
+
+// WorkspacesUsing returns the IDs of the workspaces with a tab that picks
+// the layout id.
+func (c Config) WorkspacesUsing(id string) []string {
+	var ids []string
+	for _, w := range c.Workspaces {
+		for _, t := range w.Tabs {
+			if t.Layout == id {
+				ids = append(ids, w.ID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// ResolveWorkspace turns the workspace with the given ID into tabs ready to
+// run, looking layouts up in layouts.
+func (c Config) ResolveWorkspace(id string, layouts []layout.Layout) (layout.Workspace, error) {
+	var ws *Workspace
+	for i := range c.Workspaces {
+		if c.Workspaces[i].ID == id {
+			ws = &c.Workspaces[i]
+		}
+	}
+	if ws == nil {
+		return layout.Workspace{}, fmt.Errorf("workspace '%s' not found — run 'tyle up' to see available workspaces", id)
+	}
+	if len(ws.Tabs) == 0 {
+		return layout.Workspace{}, fmt.Errorf("workspace '%s' has no tabs", id)
+	}
+
+	out := layout.Workspace{ID: ws.ID, Name: ws.Name}
+	if out.Name == "" {
+		out.Name = ws.ID
+	}
+
+	for i, t := range ws.Tabs {
+		name := t.Title
+		if name == "" {
+			name = fmt.Sprintf("tab %d", i+1)
+		}
+
+		l, err := t.resolveLayout(name, layouts)
+		if err != nil {
+			return layout.Workspace{}, fmt.Errorf("workspace '%s', %s: %w", id, name, err)
+		}
+		if len(t.Commands) > l.PaneCount {
+			return layout.Workspace{}, fmt.Errorf("workspace '%s', %s: %d commands for %d panes", id, name, len(t.Commands), l.PaneCount)
+		}
+
+		out.Tabs = append(out.Tabs, layout.Tab{
+			Title:    t.Title,
+			Layout:   l,
+			Commands: t.Commands,
+			Window:   t.Window,
+		})
+	}
+	return out, nil
+}
+
+func (t WorkspaceTab) resolveLayout(name string, layouts []layout.Layout) (layout.Layout, error) {
+	specs := 0
+	for _, set := range []bool{t.Layout != "", len(t.Columns) > 0, len(t.Steps) > 0} {
+		if set {
+			specs++
+		}
+	}
+	if specs > 1 {
+		return layout.Layout{}, fmt.Errorf("set only one of layout, columns and steps")
+	}
+
+	switch {
+	case t.Layout != "":
+		for _, l := range layouts {
+			if l.ID == t.Layout {
+				return l, nil
+			}
+		}
+		return layout.Layout{}, fmt.Errorf("layout '%s' not found", t.Layout)
+
+	case len(t.Columns) > 0:
+		for _, rows := range t.Columns {
+			if rows < 1 {
+				return layout.Layout{}, fmt.Errorf("columns need at least one row each")
+			}
+		}
+		return layout.GenerateLayout(name, t.Columns), nil
+
+	case len(t.Steps) > 0:
+		cl := CustomLayout{ID: layout.Slugify(name), Name: name, Steps: t.Steps, PaneCount: 1}
+		l := Config{CustomLayouts: []CustomLayout{cl}}.ToLayouts()[0]
+		for _, s := range l.Steps {
+			if s.CreatesPane() {
+				l.PaneCount++
+			}
+		}
+		return l, nil
+	}
+
+	return layout.Layout{ID: layout.Slugify(name), Name: name, PaneCount: 1}, nil
+}
@@ -3,6 +3,8 @@ package engine
 import (
 	"fmt"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -11,22 +13,228 @@ type KeyCombo struct {
 	Modifiers []string // "command", "shift", "control", "option"
 }
 
+// keyCodes maps Ghostty key names that have no printable character to
+// macOS virtual key codes.
+var keyCodes = map[string]int{
+	"left":        123,
+	"right":       124,
+	"down":        125,
+	"up":          126,
+	"arrow_left":  123,
+	"arrow_right": 124,
+	"arrow_down":  125,
+	"arrow_up":    126,
+	"enter":       36,
+	"return":      36,
+	"tab":         48,
+	"space":       49,
+	"backspace":   51,
+	"escape":      53,
+	"home":        115,
+	"page_up":     116,
+	"delete":      117,
+	"end":         119,
+	"page_down":   121,
+}
+
+var modifierNames = map[string]string{
+	"command": "cmd",
+	"control": "ctrl",
+	"option":  "alt",
+	"shift":   "shift",
+}
+
+// String formats the combo the way Ghostty's keybind triggers are written,
+// e.g. "cmd+shift+d".
+func (c KeyCombo) String() string {
+	parts := make([]string, 0, len(c.Modifiers)+1)
+	for _, m := range c.Modifiers {
+		if name, ok := modifierNames[m]; ok {
+			m = name
+		}
+		parts = append(parts, m)
+	}
+	return strings.Join(append(parts, c.Key), "+")
+}
+
+// modifierSymbols lists the modifiers in the order Symbol writes them.
+var modifierSymbols = []struct{ name, symbol string }{
+	{"command", "⌘"},
+	{"control", "⌃"},
+	{"option", "⌥"},
+	{"shift", "⇧"},
+}
+
+var keySymbols = map[string]string{
+	"left":        "←",
+	"right":       "→",
+	"down":        "↓",
+	"up":          "↑",
+	"arrow_left":  "←",
+	"arrow_right": "→",
+	"arrow_down":  "↓",
+	"arrow_up":    "↑",
+	"enter":       "↩",
+	"return":      "↩",
+	"tab":         "⇥",
+	"space":       "Space",
+	"backspace":   "⌫",
+	"escape":      "⎋",
+	"delete":      "⌦",
+	"home":        "↖",
+	"end":         "↘",
+	"page_up":     "⇞",
+	"page_down":   "⇟",
+}
+
+// Symbol formats the combo with the macOS modifier symbols, e.g. "⌘⇧D".
+func (c KeyCombo) Symbol() string {
+	var b strings.Builder
+	for _, m := range modifierSymbols {
+		for _, held := range c.Modifiers {
+			if held == m.name {
+				b.WriteString(m.symbol)
+				break
+			}
+		}
+	}
+	if sym, ok := keySymbols[strings.ToLower(c.Key)]; ok {
+		b.WriteString(sym)
+	} else {
+		b.WriteString(strings.ToUpper(c.Key))
+	}
+	return b.String()
+}
+
+// Equal reports whether two combos press the same keys, whatever order
+// their modifiers are listed in.
+func (c KeyCombo) Equal(other KeyCombo) bool {
+	if !strings.EqualFold(c.Key, other.Key) || len(c.Modifiers) != len(other.Modifiers) {
+		return false
+	}
+	for _, m := range c.Modifiers {
+		if !slices.Contains(other.Modifiers, m) {
+			return false
+		}
+	}
+	return true
+}
+
 func SendKeystroke(combo KeyCombo) error {
+	cmd := exec.Command("osascript", "-e", keystrokeScript(combo))
+	return cmd.Run()
+}
+
+// keystrokeScript is the AppleScript line that presses combo in Ghostty.
+func keystrokeScript(combo KeyCombo) string {
 	mods := make([]string, len(combo.Modifiers))
 	for i, m := range combo.Modifiers {
 		mods[i] = m + " down"
 	}
 	modStr := strings.Join(mods, ", ")
 
-	script := fmt.Sprintf(
-		`tell application "System Events" to tell process "Ghostty" to keystroke "%s" using {%s}`,
-		combo.Key, modStr,
+	press := "keystroke " + appleScriptString(combo.Key)
+	if code, ok := keyCodes[strings.ToLower(combo.Key)]; ok {
+		press = fmt.Sprintf("key code %d", code)
+	}
+
+	return fmt.Sprintf(
+		`tell application "System Events" to tell process "Ghostty" to %s using {%s}`,
+		press, modStr,
 	)
+}
 
-	cmd := exec.Command("osascript", "-e", script)
+// TypeText types text into the focused pane. Newlines press return and
+// tabs press tab.
+func TypeText(text string) error {
+	if text == "" {
+		return nil
+	}
+	cmd := exec.Command("osascript", "-e", typeScript(text))
 	return cmd.Run()
 }
 
+func typeScript(text string) string {
+	const prefix = `tell application "System Events" to tell process "Ghostty" to `
+
+	var lines []string
+	var chunk strings.Builder
+	flush := func() {
+		if chunk.Len() > 0 {
+			lines = append(lines, prefix+"keystroke "+appleScriptString(chunk.String()))
+			chunk.Reset()
+		}
+	}
+	for _, r := range text {
+		switch r {
+		case '\n', '\r':
+			flush()
+			lines = append(lines, prefix+"key code 36")
+		case '\t':
+			flush()
+			lines = append(lines, prefix+"key code 48")
+		default:
+			chunk.WriteRune(r)
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// appleScriptString makes an AppleScript expression for s. Printable ASCII
+// goes in a quoted literal; anything else is joined in with
+// (character id N) so that the script itself stays plain ASCII.
+func appleScriptString(s string) string {
+	var parts []string
+	var lit strings.Builder
+	open := false
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f {
+			if !open {
+				lit.Reset()
+				open = true
+			}
+			if r == '"' || r == '\\' {
+				lit.WriteByte('\\')
+			}
+			lit.WriteRune(r)
+			continue
+		}
+		if open {
+			parts = append(parts, `"`+lit.String()+`"`)
+			open = false
+		}
+		parts = append(parts, fmt.Sprintf("(character id %d)", r))
+	}
+	if open {
+		parts = append(parts, `"`+lit.String()+`"`)
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " & ")
+}
+
+// SelectedTab returns the 1-based position of the selected tab in Ghostty's
+// front window, or 0 if it can't be read, e.g. while the window has a single
+// tab and no tab bar.
+func SelectedTab() int {
+	cmd := exec.Command("osascript", "-e", `tell application "System Events" to tell process "Ghostty"
+	set i to 0
+	repeat with b in radio buttons of tab group 1 of front window
+		set i to i + 1
+		if value of b is 1 then return i
+	end repeat
+end tell
+return 0`)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	return n
+}
+
 func EnsureGhosttyFocused() error {
 	cmd := exec.Command("osascript", "-e",
 		`tell application "Ghostty" to activate`)
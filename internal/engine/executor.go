@@ -1,12 +1,69 @@
 package engine
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/atkntepe/tyle/internal/layout"
 )
 
+type Options struct {
+	DelayMs      int
+	AutoEqualize bool
+	// Commands are typed into panes as they are created: the first into
+	// the starting pane, then one into each new split.
+	Commands []string
+	// NewTab marks a layout applied in a tab tyle just opened, rather than
+	// the one it runs in. Its panes are journalled without a pane, since
+	// tyle can't find that tab's pane again later, so undo leaves them alone.
+	NewTab bool
+}
+
+// ForLayout applies the layout's own overrides on top of the global options.
+func (o Options) ForLayout(l layout.Layout) Options {
+	if l.SplitDelayMs > 0 {
+		o.DelayMs = l.SplitDelayMs
+	}
+	if l.Equalize != nil {
+		o.AutoEqualize = *l.Equalize
+	}
+	return o
+}
+
+// ResolveSteps returns the steps that will actually run: the layout's own
+// steps, then the final focus move and an equalize if enabled. Layouts
+// written before equalizing was automatic often end with their own
+// equalize; the automatic one is skipped for them rather than run twice.
+func ResolveSteps(l layout.Layout, opts Options) []layout.LayoutStep {
+	opts = opts.ForLayout(l)
+
+	steps := append([]layout.LayoutStep{}, l.Steps...)
+	if l.FinalFocus != "" {
+		steps = append(steps, layout.LayoutStep{Action: layout.ActionFocus, Direction: l.FinalFocus})
+	}
+	if opts.AutoEqualize && !endsEqualized(l.Steps) {
+		steps = append(steps, layout.LayoutStep{Action: layout.ActionEqualize})
+	}
+	return steps
+}
+
+// endsEqualized reports whether no split or resize follows the layout's
+// last equalize step, so sizes are already even once it has run.
+func endsEqualized(steps []layout.LayoutStep) bool {
+	for i := len(steps) - 1; i >= 0; i-- {
+		switch steps[i].Action {
+		case layout.ActionEqualize:
+			return true
+		case layout.ActionSplit, layout.ActionResize:
+			return false
+		}
+	}
+	return false
+}
+
 func ValidateBindings(l layout.Layout, bindings map[string]KeyCombo) []string {
 	var missing []string
 	for _, step := range l.Steps {
@@ -18,6 +75,17 @@ func ValidateBindings(l layout.Layout, bindings map[string]KeyCombo) []string {
 			action = fmt.Sprintf("goto_split:%s", step.Direction)
 		case layout.ActionEqualize:
 			action = "equalize_splits"
+		case layout.ActionGhostty:
+			if step.Ghostty == "" {
+				missing = append(missing, "(ghostty step with no action)")
+				continue
+			}
+			action = step.Ghostty
+		case layout.ActionResize:
+			if _, _, ok := resizeBinding(step.Direction, step.Amount, bindings); !ok {
+				missing = append(missing, fmt.Sprintf("resize_split:%s,<pixels>", step.Direction))
+			}
+			continue
 		default:
 			continue
 		}
@@ -28,7 +96,40 @@ func ValidateBindings(l layout.Layout, bindings map[string]KeyCombo) []string {
 	return missing
 }
 
-func ExecuteLayout(l layout.Layout, bindings map[string]KeyCombo, delayMs int) error {
+type StepStatus int
+
+const (
+	StepStarted StepStatus = iota
+	StepDone
+	StepFailed
+)
+
+// Event reports progress while a layout is applied. Index is the position
+// in the resolved steps and Panes the number of panes after the step.
+type Event struct {
+	Index  int
+	Step   layout.LayoutStep
+	Status StepStatus
+	Panes  int
+	Err    error
+}
+
+func ExecuteLayout(l layout.Layout, bindings map[string]KeyCombo, opts Options) error {
+	return ExecuteLayoutContext(context.Background(), l, bindings, opts, nil)
+}
+
+// ExecuteLayoutContext is ExecuteLayout with cancellation between steps and
+// an optional progress callback.
+func ExecuteLayoutContext(ctx context.Context, l layout.Layout, bindings map[string]KeyCombo, opts Options, progress func(Event)) error {
+	if problems := layout.Validate(l); len(problems) > 0 {
+		return fmt.Errorf("layout '%s' is invalid: %s", l.ID, strings.Join(problems, "; "))
+	}
+	opts = opts.ForLayout(l)
+	l.Steps = ResolveSteps(l, opts)
+	if progress == nil {
+		progress = func(Event) {}
+	}
+
 	if !CheckAccessibilityPermission() {
 		return fmt.Errorf("accessibility permission required — grant access in System Settings > Privacy & Security > Accessibility")
 	}
@@ -51,48 +152,170 @@ func ExecuteLayout(l layout.Layout, bindings map[string]KeyCombo, delayMs int) e
 		return fmt.Errorf("failed to focus ghostty: %w", err)
 	}
 
-	time.Sleep(100 * time.Millisecond)
+	if err := sleep(ctx, 100*time.Millisecond); err != nil {
+		return err
+	}
 
-	delay := time.Duration(delayMs) * time.Millisecond
+	delay := time.Duration(opts.DelayMs) * time.Millisecond
+	panes := 1
 
-	for _, step := range l.Steps {
-		switch step.Action {
-		case layout.ActionSplit:
-			action := fmt.Sprintf("new_split:%s", step.Direction)
-			combo, ok := bindings[action]
-			if !ok {
-				return fmt.Errorf("no keybinding found for %s — add it to your Ghostty config", action)
-			}
-			if err := SendKeystroke(combo); err != nil {
-				return fmt.Errorf("failed to execute %s: %w", action, err)
+	// runCommand types the command for the newest pane, giving the shell in
+	// a fresh split a moment to start first.
+	runCommand := func() error {
+		if panes > len(opts.Commands) || opts.Commands[panes-1] == "" {
+			return nil
+		}
+		if panes > 1 {
+			if err := sleep(ctx, delay); err != nil {
+				return err
 			}
+		}
+		if err := TypeText(opts.Commands[panes-1] + "\n"); err != nil {
+			return fmt.Errorf("failed to type command for pane %d: %w", panes, err)
+		}
+		return nil
+	}
+	if err := runCommand(); err != nil {
+		return err
+	}
 
-		case layout.ActionFocus:
-			action := fmt.Sprintf("goto_split:%s", step.Direction)
-			combo, ok := bindings[action]
-			if !ok {
-				return fmt.Errorf("no keybinding found for %s — add it to your Ghostty config", action)
-			}
-			if err := SendKeystroke(combo); err != nil {
-				return fmt.Errorf("failed to execute %s: %w", action, err)
-			}
+	// Journal the panes created, even if a later step fails, so that
+	// `tyle undo` can close them.
+	pane := ""
+	if !opts.NewTab {
+		pane = CurrentPane()
+	}
+	// Only panes in this tab count; a step that opens a tab or window
+	// leaves the rest elsewhere.
+	done := 0
+	defer func() {
+		if created := layout.CountPanes(l.Steps[:done]) - 1; created > 0 {
+			before, _ := layout.PanesAround(l.Steps[:done])
+			_ = appendJournal(JournalEntry{LayoutID: l.ID, Pane: pane, Panes: created, Before: before, Time: time.Now()})
+		}
+	}()
 
-		case layout.ActionEqualize:
-			combo, ok := bindings["equalize_splits"]
-			if !ok {
-				continue
+	for i, step := range l.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress(Event{Index: i, Step: step, Status: StepStarted, Panes: panes})
+
+		if err := runStep(ctx, step, bindings); err != nil {
+			progress(Event{Index: i, Step: step, Status: StepFailed, Panes: panes, Err: err})
+			return err
+		}
+		done = i + 1
+		if step.CreatesPane() {
+			panes++
+			if err := runCommand(); err != nil {
+				progress(Event{Index: i, Step: step, Status: StepFailed, Panes: panes, Err: err})
+				return err
 			}
+		}
+		progress(Event{Index: i, Step: step, Status: StepDone, Panes: panes})
+
+		if step.Action == layout.ActionDelay {
+			continue
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runStep(ctx context.Context, step layout.LayoutStep, bindings map[string]KeyCombo) error {
+	switch step.Action {
+	case layout.ActionSplit, layout.ActionFocus:
+		action, combo, _, ok := stepBinding(step, bindings)
+		if !ok {
+			return fmt.Errorf("no keybinding found for %s — add it to your Ghostty config", action)
+		}
+		if err := SendKeystroke(combo); err != nil {
+			return fmt.Errorf("failed to execute %s: %w", action, err)
+		}
+
+	case layout.ActionEqualize:
+		combo, ok := bindings["equalize_splits"]
+		if !ok {
+			return nil
+		}
+		if err := SendKeystroke(combo); err != nil {
+			return fmt.Errorf("failed to equalize: %w", err)
+		}
+
+	case layout.ActionResize:
+		combo, presses, ok := resizeBinding(step.Direction, step.Amount, bindings)
+		if !ok {
+			return fmt.Errorf("no keybinding found for resize_split:%s — add it to your Ghostty config", step.Direction)
+		}
+		for i := 0; i < presses; i++ {
 			if err := SendKeystroke(combo); err != nil {
-				return fmt.Errorf("failed to equalize: %w", err)
+				return fmt.Errorf("failed to resize %s: %w", step.Direction, err)
 			}
+		}
 
-		case layout.ActionDelay:
-			time.Sleep(time.Duration(step.DelayMs) * time.Millisecond)
-			continue
+	case layout.ActionGhostty:
+		combo, ok := bindings[step.Ghostty]
+		if !ok {
+			return fmt.Errorf("no keybinding found for %s — add it to your Ghostty config", step.Ghostty)
+		}
+		if err := SendKeystroke(combo); err != nil {
+			return fmt.Errorf("failed to execute %s: %w", step.Ghostty, err)
 		}
 
-		time.Sleep(delay)
+	case layout.ActionType:
+		if err := TypeText(layout.UnescapeText(step.Text)); err != nil {
+			return fmt.Errorf("failed to type text: %w", err)
+		}
+
+	case layout.ActionDelay:
+		return sleep(ctx, time.Duration(step.DelayMs)*time.Millisecond)
 	}
 
 	return nil
 }
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// resizeBinding finds a resize_split binding for dir. Ghostty moves the
+// divider a fixed number of pixels per keypress, so it also returns how many
+// presses come closest to amount.
+func resizeBinding(dir layout.Direction, amount int, bindings map[string]KeyCombo) (KeyCombo, int, bool) {
+	if combo, ok := bindings[fmt.Sprintf("resize_split:%s,%d", dir, amount)]; ok {
+		return combo, 1, true
+	}
+
+	prefix := fmt.Sprintf("resize_split:%s,", dir)
+	var best KeyCombo
+	bestStep := 0
+	for action, combo := range bindings {
+		if !strings.HasPrefix(action, prefix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(action, prefix))
+		if err != nil || n <= 0 {
+			continue
+		}
+		if n > bestStep {
+			best, bestStep = combo, n
+		}
+	}
+	if bestStep == 0 {
+		return KeyCombo{}, 0, false
+	}
+
+	presses := (amount + bestStep/2) / bestStep
+	return best, max(presses, 1), true
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// Script formats for ExportScript.
+const (
+	ScriptAppleScript = "applescript"
+	ScriptShell       = "sh"
+)
+
+// ExportScript writes a standalone script that applies l the way
+// ExecuteLayout does: the same keystrokes, from the given bindings, with
+// the same delays, final focus and equalize. The script only needs
+// osascript, not tyle. It fails if a step has no keybinding.
+func ExportScript(l layout.Layout, bindings map[string]KeyCombo, opts Options, format string) (string, error) {
+	if format != ScriptAppleScript && format != ScriptShell {
+		return "", fmt.Errorf("unknown script format '%s' — use applescript or sh", format)
+	}
+
+	opts = opts.ForLayout(l)
+	l.Steps = ResolveSteps(l, opts)
+	if missing := ValidateBindings(l, bindings); len(missing) > 0 {
+		return "", fmt.Errorf("missing Ghostty keybindings for this layout: %s", strings.Join(missing, ", "))
+	}
+
+	delay := appleScriptDelay(opts.DelayMs)
+	lines := []string{
+		`tell application "Ghostty" to activate`,
+		appleScriptDelay(100),
+	}
+	for i, step := range l.Steps {
+		lines = append(lines, "", fmt.Sprintf("-- %d. %s", i+1, step))
+		switch step.Action {
+		case layout.ActionDelay:
+			lines = append(lines, appleScriptDelay(step.DelayMs))
+			continue
+		case layout.ActionType:
+			if text := layout.UnescapeText(step.Text); text != "" {
+				lines = append(lines, typeScript(text))
+			}
+		default:
+			_, combo, presses, _ := stepBinding(step, bindings)
+			press := keystrokeScript(combo)
+			if presses > 1 {
+				press = fmt.Sprintf("repeat %d times\n\t%s\nend repeat", presses, press)
+			}
+			lines = append(lines, press)
+		}
+		if delay != "" {
+			lines = append(lines, delay)
+		}
+	}
+	script := strings.Join(lines, "\n") + "\n"
+
+	about := fmt.Sprintf("Applies the %q tyle layout (%s) to the focused Ghostty pane.", l.Name, l.ID)
+	need := "Needs Accessibility permission for the app that runs it."
+	if format == ScriptShell {
+		return "#!/bin/sh\n" +
+			"# " + about + "\n" +
+			"# " + need + "\n" +
+			"set -e\n\n" +
+			"osascript <<'APPLESCRIPT'\n" + script + "APPLESCRIPT\n", nil
+	}
+	return "#!/usr/bin/osascript\n" +
+		"-- " + about + "\n" +
+		"-- " + need + "\n\n" +
+		script, nil
+}
+
+func appleScriptDelay(ms int) string {
+	if ms <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("delay %g", float64(ms)/1000)
+}
@@ -4,17 +4,34 @@ import (
 	"bufio"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 func DefaultKeybindings() map[string]KeyCombo {
 	return map[string]KeyCombo{
-		"new_split:right":     {Key: "d", Modifiers: []string{"command"}},
-		"new_split:down":      {Key: "d", Modifiers: []string{"command", "shift"}},
-		"goto_split:previous": {Key: "[", Modifiers: []string{"command"}},
-		"goto_split:next":     {Key: "]", Modifiers: []string{"command"}},
-		"equalize_splits":     {Key: "=", Modifiers: []string{"command", "shift"}},
-		"close_surface":       {Key: "w", Modifiers: []string{"command", "shift"}},
+		"new_split:right":       {Key: "d", Modifiers: []string{"command"}},
+		"new_split:down":        {Key: "d", Modifiers: []string{"command", "shift"}},
+		"goto_split:previous":   {Key: "[", Modifiers: []string{"command"}},
+		"goto_split:next":       {Key: "]", Modifiers: []string{"command"}},
+		"equalize_splits":       {Key: "=", Modifiers: []string{"command", "shift"}},
+		"close_surface":         {Key: "w", Modifiers: []string{"command", "shift"}},
+		"resize_split:up,10":    {Key: "up", Modifiers: []string{"command", "control"}},
+		"resize_split:down,10":  {Key: "down", Modifiers: []string{"command", "control"}},
+		"resize_split:left,10":  {Key: "left", Modifiers: []string{"command", "control"}},
+		"resize_split:right,10": {Key: "right", Modifiers: []string{"command", "control"}},
+		"new_tab":               {Key: "t", Modifiers: []string{"command"}},
+		"new_window":            {Key: "n", Modifiers: []string{"command"}},
+		"previous_tab":          {Key: "[", Modifiers: []string{"command", "shift"}},
+		"next_tab":              {Key: "]", Modifiers: []string{"command", "shift"}},
+		"goto_tab:1":            {Key: "1", Modifiers: []string{"command"}},
+		"goto_tab:2":            {Key: "2", Modifiers: []string{"command"}},
+		"goto_tab:3":            {Key: "3", Modifiers: []string{"command"}},
+		"goto_tab:4":            {Key: "4", Modifiers: []string{"command"}},
+		"goto_tab:5":            {Key: "5", Modifiers: []string{"command"}},
+		"goto_tab:6":            {Key: "6", Modifiers: []string{"command"}},
+		"goto_tab:7":            {Key: "7", Modifiers: []string{"command"}},
+		"goto_tab:8":            {Key: "8", Modifiers: []string{"command"}},
 	}
 }
 
@@ -27,10 +44,37 @@ func GhosttyConfigPath() string {
 func ParseGhosttyKeybindings(configPath string) (map[string]KeyCombo, error) {
 	bindings := DefaultKeybindings()
 
-	file, err := os.Open(configPath)
-	if err != nil {
+	err := scanGhosttyConfig(configPath, func(key, value string) {
+		if key != "keybind" {
+			return
+		}
+
+		bindParts := strings.SplitN(value, "=", 2)
+		if len(bindParts) != 2 {
+			return
+		}
+
+		trigger := strings.TrimSpace(bindParts[0])
+		action := strings.TrimSpace(bindParts[1])
+
+		combo := parseTrigger(trigger)
+		if combo != nil {
+			bindings[action] = *combo
+		}
+	})
+	if os.IsNotExist(err) {
 		return bindings, nil
 	}
+	return bindings, err
+}
+
+// scanGhosttyConfig calls fn with the key and value of every setting in a
+// Ghostty config file, in order.
+func scanGhosttyConfig(path string, fn func(key, value string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
@@ -41,31 +85,125 @@ func ParseGhosttyKeybindings(configPath string) (map[string]KeyCombo, error) {
 			continue
 		}
 
-		if !strings.HasPrefix(line, "keybind") {
-			continue
-		}
-
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
 			continue
 		}
 
-		value := strings.TrimSpace(parts[1])
-		bindParts := strings.SplitN(value, "=", 2)
-		if len(bindParts) != 2 {
-			continue
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"`)
+		fn(key, value)
+	}
+
+	return scanner.Err()
+}
+
+// GhosttyColors holds the colours a Ghostty config sets, as #rrggbb
+// strings. Unset colours are empty.
+type GhosttyColors struct {
+	Foreground string
+	Background string
+	Palette    [16]string
+}
+
+// ParseGhosttyColors reads the terminal colours from a Ghostty config,
+// starting from its theme file (if any) and applying the foreground,
+// background and palette settings on top, the same way Ghostty does.
+func ParseGhosttyColors(configPath string) (GhosttyColors, error) {
+	var colors GhosttyColors
+	var theme string
+
+	err := scanGhosttyConfig(configPath, func(key, value string) {
+		if key == "theme" {
+			theme = value
 		}
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return colors, err
+	}
 
-		trigger := strings.TrimSpace(bindParts[0])
-		action := strings.TrimSpace(bindParts[1])
+	if path := ghosttyThemePath(theme, configPath); path != "" {
+		if err := scanGhosttyConfig(path, colors.set); err != nil && !os.IsNotExist(err) {
+			return colors, err
+		}
+	}
 
-		combo := parseTrigger(trigger)
-		if combo != nil {
-			bindings[action] = *combo
+	if err := scanGhosttyConfig(configPath, colors.set); err != nil && !os.IsNotExist(err) {
+		return colors, err
+	}
+	return colors, nil
+}
+
+func (c *GhosttyColors) set(key, value string) {
+	switch key {
+	case "foreground":
+		c.Foreground = hexColor(value)
+	case "background":
+		c.Background = hexColor(value)
+	case "palette":
+		index, color, ok := strings.Cut(value, "=")
+		if !ok {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(index))
+		if err != nil || n < 0 || n >= len(c.Palette) {
+			return
 		}
+		c.Palette[n] = hexColor(strings.TrimSpace(color))
+	}
+}
+
+// hexColor normalises a Ghostty hex colour to #rrggbb. Named colours are
+// not supported and return "".
+func hexColor(value string) string {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) != 6 {
+		return ""
+	}
+	if _, err := strconv.ParseUint(value, 16, 32); err != nil {
+		return ""
+	}
+	return "#" + strings.ToLower(value)
+}
+
+// ghosttyThemePath finds the file for a Ghostty theme name. A theme that
+// differs between light and dark mode ("light:A,dark:B") resolves to the
+// dark one.
+func ghosttyThemePath(theme, configPath string) string {
+	if theme == "" {
+		return ""
+	}
+	if strings.Contains(theme, ":") {
+		var first string
+		for _, part := range strings.Split(theme, ",") {
+			mode, name, _ := strings.Cut(strings.TrimSpace(part), ":")
+			if first == "" {
+				first = name
+			}
+			if mode == "dark" {
+				first = name
+				break
+			}
+		}
+		theme = strings.TrimSpace(first)
+	}
+	if filepath.IsAbs(theme) {
+		return theme
 	}
 
-	return bindings, scanner.Err()
+	home, _ := os.UserHomeDir()
+	dirs := []string{
+		filepath.Join(filepath.Dir(configPath), "themes"),
+		filepath.Join(home, ".config", "ghostty", "themes"),
+		filepath.Join("/Applications", "Ghostty.app", "Contents", "Resources", "ghostty", "themes"),
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, theme)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
 }
 
 func parseTrigger(trigger string) *KeyCombo {
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atkntepe/tyle/internal/history"
+)
+
+// maxJournal is how many applies can be undone in a row.
+const maxJournal = 20
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// JournalEntry records how many panes an apply created, so that undo can
+// close exactly those. Pane is the terminal device of the pane the layout
+// was applied from, which tyle can find again when run in that pane; it is
+// empty when that isn't known, as for tabs a workspace opens. Before is how
+// many of the panes come before that pane in Ghostty's pane order; the rest
+// come after it.
+type JournalEntry struct {
+	LayoutID string    `json:"layout_id"`
+	Pane     string    `json:"pane,omitempty"`
+	Panes    int       `json:"panes"`
+	Before   int       `json:"before,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// After is how many of the entry's panes come after the pane the layout
+// was applied from.
+func (e JournalEntry) After() int {
+	return e.Panes - e.Before
+}
+
+// CurrentPane identifies the pane tyle runs in by its terminal device, or
+// returns "" if tyle has no controlling terminal.
+func CurrentPane() string {
+	out, err := exec.Command("ps", "-o", "tty=", "-p", strconv.Itoa(os.Getpid())).Output()
+	if err != nil {
+		return ""
+	}
+	tty := strings.TrimSpace(string(out))
+	if tty == "" || strings.Trim(tty, "?") == "" {
+		return ""
+	}
+	return tty
+}
+
+func JournalPath() string {
+	return filepath.Join(history.StateDir(), "journal.json")
+}
+
+// ReadJournal returns the journal, oldest apply first.
+func ReadJournal() ([]JournalEntry, error) {
+	data, err := os.ReadFile(JournalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", JournalPath(), err)
+	}
+	return entries, nil
+}
+
+func writeJournal(entries []JournalEntry) error {
+	if len(entries) > maxJournal {
+		entries = entries[len(entries)-maxJournal:]
+	}
+	if err := os.MkdirAll(filepath.Dir(JournalPath()), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(JournalPath(), data, 0o644)
+}
+
+func appendJournal(e JournalEntry) error {
+	entries, err := ReadJournal()
+	if err != nil {
+		entries = nil
+	}
+	return writeJournal(append(entries, e))
+}
+
+// Undo closes the panes created by the most recent apply made from the
+// pane it runs in, keeping that pane (see CloseAround). Applies made from
+// other panes are left alone.
+func Undo(ctx context.Context, bindings map[string]KeyCombo, opts Options) (JournalEntry, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	pane := CurrentPane()
+	if pane == "" {
+		return JournalEntry{}, fmt.Errorf("can't tell which pane this is — run undo from the pane the layout was applied from")
+	}
+
+	i := len(entries) - 1
+	for i >= 0 && entries[i].Pane != pane {
+		i--
+	}
+	if i < 0 {
+		return JournalEntry{}, ErrNothingToUndo
+	}
+	last := entries[i]
+
+	closed, err := closePanes(ctx, CloseAround(last.Before, last.After()), bindings, opts)
+	if closed == 0 && err != nil {
+		return last, err
+	}
+
+	// Keep whatever is left to close so that a second undo can finish.
+	// CloseAround closes the panes after the origin first.
+	rest := append([]JournalEntry{}, entries[i+1:]...)
+	entries = entries[:i]
+	if remaining := last.Panes - closed; remaining > 0 {
+		left := last
+		left.Panes = remaining
+		left.Before = min(last.Before, remaining)
+		entries = append(entries, left)
+	}
+	entries = append(entries, rest...)
+	if werr := writeJournal(entries); werr != nil && err == nil {
+		err = werr
+	}
+	return last, err
+}
@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// PlannedStep is a resolved step together with the keys it would send.
+type PlannedStep struct {
+	Step   string `json:"step" toml:"step"`
+	Action string `json:"action" toml:"action"`
+	// Ghostty is the keybinding action the step runs, if any.
+	Ghostty string `json:"ghostty,omitempty" toml:"ghostty,omitempty"`
+	// Keys is the key combination bound to Ghostty, e.g. "cmd+shift+d",
+	// sent Presses times, and Symbol the same keys as "⌘⇧D". Both are
+	// empty when Missing.
+	Keys    string `json:"keys,omitempty" toml:"keys,omitempty"`
+	Symbol  string `json:"symbol,omitempty" toml:"symbol,omitempty"`
+	Presses int    `json:"presses,omitempty" toml:"presses,omitzero"`
+	Text    string `json:"text,omitempty" toml:"text,omitempty"`
+	DelayMs int    `json:"delay_ms,omitempty" toml:"delay_ms,omitzero"`
+	Missing bool   `json:"missing,omitempty" toml:"missing,omitempty"`
+	// Conflicts lists other Ghostty actions bound to the same keys. Ghostty
+	// runs only one of them: whichever keybind comes last in its config.
+	Conflicts []string `json:"conflicts,omitempty" toml:"conflicts,omitempty"`
+}
+
+// Plan resolves a layout's steps, including the final focus and equalize,
+// against the keybindings without sending anything.
+func Plan(l layout.Layout, bindings map[string]KeyCombo, opts Options) []PlannedStep {
+	var plan []PlannedStep
+	for _, step := range ResolveSteps(l, opts) {
+		p := PlannedStep{Step: step.String(), Action: string(step.Action)}
+		switch step.Action {
+		case layout.ActionType:
+			p.Text = layout.UnescapeText(step.Text)
+		case layout.ActionDelay:
+			p.DelayMs = step.DelayMs
+		default:
+			action, combo, presses, ok := stepBinding(step, bindings)
+			p.Ghostty = action
+			if ok {
+				p.Keys = combo.String()
+				p.Symbol = combo.Symbol()
+				p.Presses = presses
+				p.Conflicts = conflicts(action, combo, bindings)
+			} else {
+				p.Missing = true
+			}
+		}
+		plan = append(plan, p)
+	}
+	return plan
+}
+
+// stepBinding finds the Ghostty action a keystroke step runs and the keys
+// bound to it, pressed presses times.
+func stepBinding(step layout.LayoutStep, bindings map[string]KeyCombo) (string, KeyCombo, int, bool) {
+	var action string
+	switch step.Action {
+	case layout.ActionSplit:
+		action = fmt.Sprintf("new_split:%s", step.Direction)
+	case layout.ActionFocus:
+		action = fmt.Sprintf("goto_split:%s", step.Direction)
+	case layout.ActionEqualize:
+		action = "equalize_splits"
+	case layout.ActionGhostty:
+		action = step.Ghostty
+	case layout.ActionResize:
+		combo, presses, ok := resizeBinding(step.Direction, step.Amount, bindings)
+		return fmt.Sprintf("resize_split:%s", step.Direction), combo, presses, ok
+	default:
+		return "", KeyCombo{}, 0, false
+	}
+	combo, ok := bindings[action]
+	return action, combo, 1, ok
+}
+
+// conflicts returns the other actions bound to combo, sorted. Resize steps
+// may use a binding with a different amount, so the resize_split action
+// itself is skipped whatever its amount.
+func conflicts(action string, combo KeyCombo, bindings map[string]KeyCombo) []string {
+	var others []string
+	for other, c := range bindings {
+		if other == action || strings.HasPrefix(other, action+",") || !c.Equal(combo) {
+			continue
+		}
+		others = append(others, other)
+	}
+	sort.Strings(others)
+	return others
+}
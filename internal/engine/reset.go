@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CloseAround lists the Ghostty actions that close the before panes just
+// ahead of the focused one in Ghostty's pane order and the after panes just
+// behind it, keeping the focused pane. Ghostty focuses the previous pane
+// after a close, so closing a following pane lands back on the kept one;
+// closing a preceding pane lands before it, and goto_split:next returns.
+func CloseAround(before, after int) []string {
+	var actions []string
+	for i := 0; i < after; i++ {
+		actions = append(actions, "goto_split:next", "close_surface")
+	}
+	for i := 0; i < before; i++ {
+		actions = append(actions, "goto_split:previous", "close_surface", "goto_split:next")
+	}
+	return actions
+}
+
+// JournalledPanes returns how many panes the journalled applies made from
+// pane created and that have not been undone, split into those before and
+// after it in Ghostty's pane order. The journal doesn't see splits made or
+// closed by hand, so the counts are only right when there were none.
+func JournalledPanes(pane string) (before, after int, err error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, e := range entries {
+		if e.Pane == pane {
+			before += e.Before
+			after += e.After()
+		}
+	}
+	return before, after, nil
+}
+
+// clearPane drops the journal entries of applies made from pane, whose
+// panes are gone.
+func clearPane(pane string) error {
+	entries, err := ReadJournal()
+	if err != nil {
+		return err
+	}
+	var kept []JournalEntry
+	for _, e := range entries {
+		if e.Pane != pane {
+			kept = append(kept, e)
+		}
+	}
+	return writeJournal(kept)
+}
+
+// Reset closes the before and after panes around the focused one with
+// CloseAround and forgets the journal entries of applies made from it,
+// since the panes they recorded are gone.
+func Reset(ctx context.Context, before, after int, bindings map[string]KeyCombo, opts Options) error {
+	if _, err := closePanes(ctx, CloseAround(before, after), bindings, opts); err != nil {
+		return err
+	}
+	if pane := CurrentPane(); pane != "" {
+		return clearPane(pane)
+	}
+	return nil
+}
+
+// closePanes sends the actions and returns how many panes it closed.
+func closePanes(ctx context.Context, actions []string, bindings map[string]KeyCombo, opts Options) (int, error) {
+	for _, action := range actions {
+		if _, ok := bindings[action]; !ok {
+			return 0, fmt.Errorf("no keybinding found for %s — add it to your Ghostty config", action)
+		}
+	}
+
+	if !CheckAccessibilityPermission() {
+		return 0, fmt.Errorf("accessibility permission required — grant access in System Settings > Privacy & Security > Accessibility")
+	}
+	if !IsGhosttyRunning() {
+		return 0, fmt.Errorf("ghostty is not running")
+	}
+	if err := EnsureGhosttyFocused(); err != nil {
+		return 0, fmt.Errorf("failed to focus ghostty: %w", err)
+	}
+	if err := sleep(ctx, 100*time.Millisecond); err != nil {
+		return 0, err
+	}
+
+	delay := time.Duration(opts.DelayMs) * time.Millisecond
+	closed := 0
+	for _, action := range actions {
+		if err := SendKeystroke(bindings[action]); err != nil {
+			return closed, fmt.Errorf("failed to execute %s: %w", action, err)
+		}
+		if action == "close_surface" {
+			closed++
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return closed, err
+		}
+	}
+	return closed, nil
+}
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// tabOpenDelay gives Ghostty time to open a tab or window and start its
+// shell before the layout's keystrokes arrive.
+const tabOpenDelay = 500 * time.Millisecond
+
+// ExecuteWorkspace opens a tab (or window) per workspace tab, applies its
+// layout and types its commands. With here, the first tab is built in the
+// current tab instead of a new one. Once done, focus goes back to the
+// first tab when all tabs share a window. progress reports the layout
+// steps of each tab, identified by its index.
+func ExecuteWorkspace(ctx context.Context, w layout.Workspace, bindings map[string]KeyCombo, opts Options, here bool, progress func(tab int, e Event)) error {
+	if progress == nil {
+		progress = func(int, Event) {}
+	}
+
+	missing := map[string]bool{}
+	var order []string
+	need := func(action string) {
+		if _, ok := bindings[action]; !ok && !missing[action] {
+			missing[action] = true
+			order = append(order, action)
+		}
+	}
+	for i, tab := range w.Tabs {
+		if i > 0 || !here {
+			need(openAction(tab))
+		}
+		resolved := tab.Layout
+		resolved.Steps = ResolveSteps(tab.Layout, opts)
+		for _, action := range ValidateBindings(resolved, bindings) {
+			need(action)
+		}
+	}
+	if len(order) > 0 {
+		msg := "missing Ghostty keybindings for this workspace:\n"
+		for _, m := range order {
+			msg += fmt.Sprintf("  - %s\n", m)
+		}
+		msg += "\nAdd these to your Ghostty config. Run 'tyle init' for instructions."
+		return fmt.Errorf("%s", msg)
+	}
+
+	if !CheckAccessibilityPermission() {
+		return fmt.Errorf("accessibility permission required — grant access in System Settings > Privacy & Security > Accessibility")
+	}
+	if !IsGhosttyRunning() {
+		return fmt.Errorf("ghostty is not running")
+	}
+	if err := EnsureGhosttyFocused(); err != nil {
+		return fmt.Errorf("failed to focus ghostty: %w", err)
+	}
+
+	sameWindow := true
+	firstTab := 0
+	for i, tab := range w.Tabs {
+		if i > 0 || !here {
+			action := openAction(tab)
+			if err := SendKeystroke(bindings[action]); err != nil {
+				return fmt.Errorf("failed to execute %s: %w", action, err)
+			}
+			if err := sleep(ctx, tabOpenDelay); err != nil {
+				return err
+			}
+		}
+		if tab.Window {
+			sameWindow = false
+		}
+
+		if i == 0 {
+			firstTab = SelectedTab()
+		}
+
+		if tab.Title != "" {
+			if err := setTitle(ctx, tab.Title, bindings); err != nil {
+				return fmt.Errorf("failed to set the title of tab %d: %w", i+1, err)
+			}
+		}
+
+		tabOpts := opts
+		tabOpts.Commands = tab.Commands
+		tabOpts.NewTab = i > 0 || !here
+		err := ExecuteLayoutContext(ctx, tab.Layout, bindings, tabOpts, func(e Event) {
+			progress(i, e)
+		})
+		if err != nil {
+			return fmt.Errorf("tab %d (%s): %w", i+1, tab.Layout.Name, err)
+		}
+	}
+
+	if !sameWindow {
+		return nil
+	}
+	return returnToTab(firstTab, len(w.Tabs), bindings)
+}
+
+// returnToTab goes back to the workspace's first tab: straight to its
+// position with goto_tab when that is known and bound, otherwise by
+// stepping back over the tabs opened after it with previous_tab.
+func returnToTab(first, tabs int, bindings map[string]KeyCombo) error {
+	action := fmt.Sprintf("goto_tab:%d", first)
+	if combo, ok := bindings[action]; ok && first > 0 {
+		if err := SendKeystroke(combo); err != nil {
+			return fmt.Errorf("failed to execute %s: %w", action, err)
+		}
+		return nil
+	}
+
+	combo, ok := bindings["previous_tab"]
+	if !ok {
+		return nil
+	}
+	for i := 1; i < tabs; i++ {
+		if err := SendKeystroke(combo); err != nil {
+			return fmt.Errorf("failed to execute previous_tab: %w", err)
+		}
+	}
+	return nil
+}
+
+// setTitle names the focused tab. With a prompt_surface_title keybinding it
+// fills in Ghostty's title prompt, leaving the shell alone. Without one it
+// falls back to typing titleCommand into the shell, where it shows in the
+// pane and may race with the pane's command; the leading space keeps it out
+// of the history of shells that ignore space-prefixed commands.
+func setTitle(ctx context.Context, title string, bindings map[string]KeyCombo) error {
+	combo, ok := bindings["prompt_surface_title"]
+	if !ok {
+		return TypeText(" " + titleCommand(title) + "\n")
+	}
+
+	if err := SendKeystroke(combo); err != nil {
+		return err
+	}
+	if err := sleep(ctx, 200*time.Millisecond); err != nil {
+		return err
+	}
+	if err := SendKeystroke(KeyCombo{Key: "a", Modifiers: []string{"command"}}); err != nil {
+		return err
+	}
+	return TypeText(title + "\n")
+}
+
+func openAction(tab layout.Tab) string {
+	if tab.Window {
+		return "new_window"
+	}
+	return "new_tab"
+}
+
+// titleCommand is a shell command that sets the tab title with an OSC 2
+// escape sequence.
+func titleCommand(title string) string {
+	quoted := "'" + strings.ReplaceAll(title, "'", `'\''`) + "'"
+	return `printf '\033]2;%s\007' ` + quoted
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// maxEntries caps the history file; older applies are dropped on write.
+const maxEntries = 500
+
+// Entry records one applied layout.
+type Entry struct {
+	LayoutID string    `json:"layout_id"`
+	Time     time.Time `json:"time"`
+	Dir      string    `json:"dir,omitempty"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// StateDir is tyle's directory under $XDG_STATE_HOME, falling back to
+// ~/.local/state.
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "tyle")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", "tyle")
+}
+
+func Path() string {
+	return filepath.Join(StateDir(), "history.jsonl")
+}
+
+// Load reads the history, oldest first. A missing file is an empty
+// history; lines that fail to parse are skipped.
+func Load() ([]Entry, error) {
+	file, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Record appends an entry, trimming the file to the newest maxEntries.
+func Record(e Entry) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, e)
+	if len(entries) > maxEntries {
+		entries = entries[len(entries)-maxEntries:]
+	}
+
+	if err := os.MkdirAll(StateDir(), 0o755); err != nil {
+		return err
+	}
+
+	tmp := Path() + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(file)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, Path())
+}
+
+// Recent returns the IDs of successfully applied layouts, most recent
+// first, each once.
+func Recent(entries []Entry) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !e.Success || seen[e.LayoutID] {
+			continue
+		}
+		seen[e.LayoutID] = true
+		ids = append(ids, e.LayoutID)
+	}
+	return ids
+}
+
+// Counts returns how many times each layout was applied successfully.
+func Counts(entries []Entry) map[string]int {
+	counts := map[string]int{}
+	for _, e := range entries {
+		if e.Success {
+			counts[e.LayoutID]++
+		}
+	}
+	return counts
+}
+
+// Newest returns entries newest first, limited to n (all if n <= 0).
+func Newest(entries []Entry, n int) []Entry {
+	out := append([]Entry{}, entries...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.After(out[j].Time)
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -43,7 +43,6 @@ func GenerateLayout(name string, rowsPerCol []int) Layout {
 		Preview:     preview,
 		Steps:       steps,
 		PaneCount:   totalPanes,
-		FinalFocus:  Previous,
 	}
 }
 
@@ -72,8 +71,6 @@ func generateSteps(rowsPerCol []int) []LayoutStep {
 		steps = append(steps, LayoutStep{Action: ActionFocus, Direction: Previous})
 	}
 
-	steps = append(steps, LayoutStep{Action: ActionEqualize})
-
 	return steps
 }
 
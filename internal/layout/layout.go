@@ -1,5 +1,10 @@
 package layout
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Direction string
 
 const (
@@ -18,12 +23,92 @@ const (
 	ActionFocus    StepAction = "focus"
 	ActionEqualize StepAction = "equalize"
 	ActionDelay    StepAction = "delay"
+	ActionResize   StepAction = "resize"
+	// ActionGhostty runs any Ghostty keybinding action, named in Ghostty.
+	ActionGhostty StepAction = "ghostty"
+	// ActionType types Text into the focused pane.
+	ActionType StepAction = "type"
 )
 
 type LayoutStep struct {
 	Action    StepAction
 	Direction Direction
 	DelayMs   int
+	// Amount is the resize distance in pixels.
+	Amount int
+	// Ghostty is the Ghostty action for ghostty steps, with its parameter
+	// after a colon as in the keybind, e.g. "increase_font_size:2".
+	Ghostty string
+	// Text is typed by type steps. See UnescapeText for the escapes it
+	// understands.
+	Text string
+}
+
+func (s LayoutStep) String() string {
+	switch s.Action {
+	case ActionSplit:
+		return fmt.Sprintf("Split %s", s.Direction)
+	case ActionFocus:
+		return fmt.Sprintf("Focus %s", s.Direction)
+	case ActionEqualize:
+		return "Equalize splits"
+	case ActionDelay:
+		return fmt.Sprintf("Delay %dms", s.DelayMs)
+	case ActionResize:
+		return fmt.Sprintf("Resize %s %dpx", s.Direction, s.Amount)
+	case ActionGhostty:
+		return fmt.Sprintf("Ghostty %s", s.Ghostty)
+	case ActionType:
+		return fmt.Sprintf("Type %q", UnescapeText(s.Text))
+	}
+	return string(s.Action)
+}
+
+// UnescapeText expands \n, \t and \\ in type step text, so that text
+// written in single-quoted TOML strings or on the command line can still
+// press return and tab. Other backslashes are kept as they are.
+func UnescapeText(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			switch runes[i+1] {
+			case 'n':
+				b.WriteRune('\n')
+				i++
+				continue
+			case 't':
+				b.WriteRune('\t')
+				i++
+				continue
+			case '\\':
+				b.WriteRune('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// CreatesPane reports whether the step adds a split, including ghostty
+// steps that run new_split.
+func (s LayoutStep) CreatesPane() bool {
+	switch s.Action {
+	case ActionSplit:
+		return true
+	case ActionGhostty:
+		return strings.HasPrefix(s.Ghostty, "new_split")
+	}
+	return false
+}
+
+// OpensTab reports whether the step is a ghostty step that opens a tab or
+// window. Steps after it run there, so its panes aren't the layout's.
+func (s LayoutStep) OpensTab() bool {
+	name, _, _ := strings.Cut(s.Ghostty, ":")
+	return s.Action == ActionGhostty && (name == "new_tab" || name == "new_window")
 }
 
 type Layout struct {
@@ -33,5 +118,10 @@ type Layout struct {
 	Preview     []string
 	Steps       []LayoutStep
 	PaneCount   int
-	FinalFocus  Direction
+
+	// FinalFocus, Equalize and SplitDelayMs override the global settings
+	// for this layout when set.
+	FinalFocus   Direction
+	Equalize     *bool
+	SplitDelayMs int
 }
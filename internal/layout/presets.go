@@ -29,10 +29,8 @@ func twoColumns() Layout {
 		Steps: []LayoutStep{
 			{Action: ActionSplit, Direction: Right},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  2,
-		FinalFocus: Previous,
+		PaneCount: 2,
 	}
 }
 
@@ -51,10 +49,8 @@ func twoRows() Layout {
 		Steps: []LayoutStep{
 			{Action: ActionSplit, Direction: Down},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  2,
-		FinalFocus: Previous,
+		PaneCount: 2,
 	}
 }
 
@@ -74,10 +70,8 @@ func threeColumns() Layout {
 			{Action: ActionSplit, Direction: Right},
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -98,10 +92,8 @@ func mainRightStack() Layout {
 			{Action: ActionSplit, Direction: Down},
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -122,10 +114,8 @@ func leftStackMain() Layout {
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionSplit, Direction: Down},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -146,10 +136,8 @@ func mainSideStack() Layout {
 			{Action: ActionSplit, Direction: Down},
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -172,10 +160,8 @@ func grid2x2() Layout {
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionSplit, Direction: Down},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  4,
-		FinalFocus: Previous,
+		PaneCount: 4,
 	}
 }
 
@@ -196,10 +182,8 @@ func mainTopTwoBottom() Layout {
 			{Action: ActionSplit, Direction: Right},
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -220,10 +204,8 @@ func twoTopOneBottom() Layout {
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionSplit, Direction: Right},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  3,
-		FinalFocus: Previous,
+		PaneCount: 3,
 	}
 }
 
@@ -246,9 +228,7 @@ func threeTopOneBottom() Layout {
 			{Action: ActionSplit, Direction: Right},
 			{Action: ActionFocus, Direction: Previous},
 			{Action: ActionFocus, Direction: Previous},
-			{Action: ActionEqualize},
 		},
-		PaneCount:  4,
-		FinalFocus: Previous,
+		PaneCount: 4,
 	}
 }
@@ -0,0 +1,194 @@
+package layout
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Simulate plays steps on a single pane the way Ghostty would and returns
+// the resulting pane tree and the pane left focused. New splits take focus,
+// previous and next wrap around in pane order, and resizes move dividers
+// by their share of the reference window size. Ghostty steps that split,
+// move focus, equalize, resize or close are followed; the rest don't
+// change the tree. Simulation stops at a step that opens a tab or window,
+// since the steps after it shape that one.
+func Simulate(steps []LayoutStep) (*Node, *Node) {
+	tree := NewTree()
+	return tree, simulate(tree, steps)
+}
+
+// originLabel marks the starting pane in PanesAround. Splits keep a pane's
+// label on the old half, so the mark stays with that pane.
+const originLabel = "\x00origin"
+
+// PanesAround plays steps like Simulate and returns how many of the panes
+// they create come before and after the starting pane in Ghostty's pane
+// order, the order goto_split:previous and goto_split:next walk.
+func PanesAround(steps []LayoutStep) (before, after int) {
+	tree := &Node{Label: originLabel}
+	simulate(tree, steps)
+	leaves := tree.Leaves()
+	for i, leaf := range leaves {
+		if leaf.Label == originLabel {
+			return i, len(leaves) - i - 1
+		}
+	}
+	return 0, len(leaves) - 1
+}
+
+func simulate(tree *Node, steps []LayoutStep) *Node {
+	focus := tree
+	for _, step := range steps {
+		if step.OpensTab() {
+			break
+		}
+		switch step.Action {
+		case ActionSplit:
+			focus = splitPane(focus, step.Direction, tree)
+		case ActionFocus:
+			focus = moveFocus(tree, focus, step.Direction)
+		case ActionEqualize:
+			equalize(tree)
+		case ActionResize:
+			resizePane(tree, focus, step.Direction, step.Amount)
+		case ActionGhostty:
+			name, param, _ := strings.Cut(step.Ghostty, ":")
+			switch name {
+			case "new_split":
+				focus = splitPane(focus, Direction(param), tree)
+			case "goto_split":
+				focus = moveFocus(tree, focus, Direction(param))
+			case "equalize_splits":
+				equalize(tree)
+			case "resize_split":
+				dir, amount, _ := strings.Cut(param, ",")
+				px, _ := strconv.Atoi(amount)
+				resizePane(tree, focus, Direction(dir), px)
+			case "close_surface":
+				if tree.PaneCount() > 1 {
+					focus = tree.Remove(focus)
+				}
+			}
+		}
+	}
+	return focus
+}
+
+// splitPane splits leaf and returns the new pane. Splits left and up put
+// the new pane first; auto splits along the longer side, as Ghostty does.
+func splitPane(leaf *Node, dir Direction, tree *Node) *Node {
+	if dir == "auto" || dir == "" {
+		r := tree.Rects()[leaf]
+		dir = Down
+		if r.W*ReferenceWidthPx >= r.H*ReferenceHeightPx {
+			dir = Right
+		}
+	}
+
+	switch dir {
+	case Left, Up:
+		axis := Right
+		if dir == Up {
+			axis = Down
+		}
+		leaf.SplitLeaf(axis)
+		leaf.First, leaf.Second = leaf.Second, leaf.First
+		return leaf.First
+	case Down:
+		return leaf.SplitLeaf(Down)
+	}
+	return leaf.SplitLeaf(Right)
+}
+
+func moveFocus(tree, focus *Node, dir Direction) *Node {
+	switch dir {
+	case "top":
+		dir = Up
+	case "bottom":
+		dir = Down
+	}
+
+	if dir == Previous || dir == Next {
+		leaves := tree.Leaves()
+		for i, leaf := range leaves {
+			if leaf != focus {
+				continue
+			}
+			if dir == Next {
+				return leaves[(i+1)%len(leaves)]
+			}
+			return leaves[(i+len(leaves)-1)%len(leaves)]
+		}
+		return focus
+	}
+
+	if next := tree.Neighbor(focus, dir); next != nil {
+		return next
+	}
+	return focus
+}
+
+// equalize gives every pane along a row or column the same share, the way
+// equalize_splits weighs each side of a split by the panes it holds.
+func equalize(n *Node) {
+	if n.IsLeaf() {
+		return
+	}
+	ac, ar := n.First.span()
+	bc, br := n.Second.span()
+	if n.Split == Right {
+		n.Ratio = float64(ac) / float64(ac+bc)
+	} else {
+		n.Ratio = float64(ar) / float64(ar+br)
+	}
+	equalize(n.First)
+	equalize(n.Second)
+}
+
+// resizePane moves the divider of the closest split around leaf that runs
+// across dir, by px on the reference window.
+func resizePane(tree, leaf *Node, dir Direction, px int) {
+	axis, sign := Right, 1.0
+	switch dir {
+	case Left:
+		sign = -1
+	case Up:
+		axis, sign = Down, -1
+	case Down:
+		axis = Down
+	}
+
+	for child := leaf; ; {
+		parent := tree.Parent(child)
+		if parent == nil {
+			return
+		}
+		if parent.Split == axis {
+			r := nodeRect(tree, parent, Rect{W: 1, H: 1})
+			size := r.W * ReferenceWidthPx
+			if axis == Down {
+				size = r.H * ReferenceHeightPx
+			}
+			if size > 0 {
+				parent.Ratio = clampRatio(parent.Ratio + sign*float64(px)/size)
+			}
+			return
+		}
+		child = parent
+	}
+}
+
+func nodeRect(n, target *Node, r Rect) Rect {
+	if n == target || n.IsLeaf() {
+		return r
+	}
+	a, b := n.divide(r)
+	if n.First == target || n.First.Parent(target) != nil {
+		return nodeRect(n.First, target, a)
+	}
+	return nodeRect(n.Second, target, b)
+}
+
+func clampRatio(r float64) float64 {
+	return min(max(r, 0.1), 0.9)
+}
@@ -0,0 +1,116 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseSpec reads a layout written in a small notation: "|" puts panes side
+// by side, "/" stacks them, and "/" binds tighter than "|", so "A | B/C" is
+// a pane on the left and two stacked on the right. Parentheses group, and
+// each pane is a label, or "." for an unlabelled pane. Panes joined by the
+// same operator get equal shares.
+func ParseSpec(spec string) (*Node, error) {
+	p := &specParser{input: []rune(spec)}
+	tree, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return tree, nil
+}
+
+type specParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *specParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("spec at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *specParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *specParser) peek() rune {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *specParser) expr() (*Node, error) {
+	return p.joined('|', Right, p.column)
+}
+
+func (p *specParser) column() (*Node, error) {
+	return p.joined('/', Down, p.item)
+}
+
+// joined parses operands separated by op and splits them evenly in dir.
+func (p *specParser) joined(op rune, dir Direction, operand func() (*Node, error)) (*Node, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*Node{first}
+	for p.peek() == op {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, next)
+	}
+	return join(nodes, dir), nil
+}
+
+func join(nodes []*Node, dir Direction) *Node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	return &Node{
+		Split:  dir,
+		Ratio:  1 / float64(len(nodes)),
+		First:  nodes[0],
+		Second: join(nodes[1:], dir),
+	}
+}
+
+func (p *specParser) item() (*Node, error) {
+	switch r := p.peek(); {
+	case r == 0:
+		return nil, p.errorf("expected a pane")
+	case r == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return n, nil
+	case r == '.':
+		p.pos++
+		return &Node{}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("|/(). \t", p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return &Node{Label: string(p.input[start:p.pos])}, nil
+}
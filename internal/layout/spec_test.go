@@ -0,0 +1,58 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"editor", "editor"},
+		{".", "."},
+		{"A | B", "(A | B 0.50)"},
+		{"A/B", "(A / B 0.50)"},
+		{"A | B | C", "(A | (B | C 0.50) 0.33)"},
+		{"A | B/C", "(A | (B / C 0.50) 0.50)"},
+		{"A/B | C", "((A / B 0.50) | C 0.50)"},
+		{"(A | B) / C", "((A | B 0.50) / C 0.50)"},
+		{"((A))", "A"},
+		{". | (. / . / .)", "(. | (. / (. / . 0.50) 0.33) 0.50)"},
+		{"  vim|\tlogs / tests ", "(vim | (logs / tests 0.50) 0.50)"},
+		{"main-1 | side_2", "(main-1 | side_2 0.50)"},
+	}
+	for _, tt := range tests {
+		tree, err := ParseSpec(tt.in)
+		if err != nil {
+			t.Errorf("ParseSpec(%q): %v", tt.in, err)
+			continue
+		}
+		if got := shape(tree); got != tt.want {
+			t.Errorf("ParseSpec(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "position 1: expected a pane"},
+		{"A |", "position 4: expected a pane"},
+		{"(A | B", "expected )"},
+		{"A B", "position 3: unexpected 'B'"},
+		{"A | )", "unexpected ')'"},
+		{"| A", "position 1: unexpected '|'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSpec(tt.in)
+		if err == nil {
+			t.Errorf("ParseSpec(%q) succeeded, want an error", tt.in)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseSpec(%q) error = %q, want it to contain %q", tt.in, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseTmux reads a tmux layout string, as printed by
+// `tmux list-windows -F '#{window_layout}'` and accepted by select-layout,
+// e.g. "5468,159x48,0,0{79x48,0,0,1,79x48,80,0,2}". Each cell is
+// "WxH,X,Y" followed by a pane ID, by "{...}" for cells side by side or by
+// "[...]" for cells stacked. Splits keep the cells' proportions. The
+// leading checksum is checked when present.
+func ParseTmux(s string) (*Node, error) {
+	s = strings.TrimSpace(s)
+	if sum, rest, ok := strings.Cut(s, ","); ok && len(sum) == 4 && !strings.Contains(sum, "x") {
+		want, err := strconv.ParseUint(sum, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("tmux layout: bad checksum '%s'", sum)
+		}
+		if got := tmuxChecksum(rest); got != uint16(want) {
+			return nil, fmt.Errorf("tmux layout: checksum is %s but the layout sums to %04x — was it copied whole?", sum, got)
+		}
+		s = rest
+	}
+
+	p := &tmuxParser{input: s}
+	cell, err := p.cell()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return cell.node, nil
+}
+
+// tmuxChecksum is tmux's layout_checksum: a 16-bit rotate-and-add over the
+// layout text.
+func tmuxChecksum(s string) uint16 {
+	var sum uint16
+	for i := 0; i < len(s); i++ {
+		sum = (sum >> 1) + ((sum & 1) << 15)
+		sum += uint16(s[i])
+	}
+	return sum
+}
+
+type tmuxCell struct {
+	node          *Node
+	width, height int
+}
+
+type tmuxParser struct {
+	input string
+	pos   int
+}
+
+func (p *tmuxParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("tmux layout at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *tmuxParser) peek() byte {
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *tmuxParser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.input) {
+			return p.errorf("expected %q, got end of layout", c)
+		}
+		return p.errorf("expected %q, got %q", c, p.input[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *tmuxParser) number() (int, error) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.errorf("expected a number")
+	}
+	return strconv.Atoi(p.input[start:p.pos])
+}
+
+// cell parses "WxH,X,Y" and what follows it: a pane ID for a pane, or a
+// bracketed list of child cells.
+func (p *tmuxParser) cell() (tmuxCell, error) {
+	var c tmuxCell
+	var err error
+	if c.width, err = p.number(); err != nil {
+		return c, err
+	}
+	if err := p.expect('x'); err != nil {
+		return c, err
+	}
+	if c.height, err = p.number(); err != nil {
+		return c, err
+	}
+	for range 2 {
+		if err := p.expect(','); err != nil {
+			return c, err
+		}
+		if _, err := p.number(); err != nil {
+			return c, err
+		}
+	}
+
+	switch p.peek() {
+	case '{', '[':
+		open := p.peek()
+		p.pos++
+		closer, dir := byte('}'), Right
+		if open == '[' {
+			closer, dir = ']', Down
+		}
+
+		var children []tmuxCell
+		for {
+			child, err := p.cell()
+			if err != nil {
+				return c, err
+			}
+			children = append(children, child)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if err := p.expect(closer); err != nil {
+			return c, err
+		}
+		c.node = joinTmux(children, dir)
+
+	default:
+		// A pane. Old tmux versions wrote no pane ID, so a comma may instead
+		// start the next cell, whose size is followed by an "x".
+		c.node = &Node{}
+		if p.peek() == ',' {
+			start := p.pos
+			p.pos++
+			if _, err := p.number(); err != nil {
+				return c, err
+			}
+			if p.peek() == 'x' {
+				p.pos = start
+			}
+		}
+	}
+	return c, nil
+}
+
+// joinTmux nests the cells of a container into splits, each giving the
+// first cell its share of the size of the cells from it onwards. tmux can
+// only split a window of odd size unevenly, so a cell within one row or
+// column of an even share gets exactly that, instead of a resize step too
+// small to matter.
+func joinTmux(cells []tmuxCell, dir Direction) *Node {
+	if len(cells) == 1 {
+		return cells[0].node
+	}
+	size := func(c tmuxCell) int {
+		if dir == Right {
+			return c.width
+		}
+		return c.height
+	}
+	total := 0
+	for _, c := range cells {
+		total += size(c)
+	}
+	ratio := 1 / float64(len(cells))
+	even := float64(total) / float64(len(cells))
+	if total > 0 && math.Abs(float64(size(cells[0]))-even) > 1 {
+		ratio = float64(size(cells[0])) / float64(total)
+	}
+	return &Node{
+		Split:  dir,
+		Ratio:  ratio,
+		First:  cells[0].node,
+		Second: joinTmux(cells[1:], dir),
+	}
+}
@@ -0,0 +1,130 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// shape describes a tree compactly: "." for a pane (or its label), and
+// "(first | second ratio)" or "(first / second ratio)" for a split.
+func shape(n *Node) string {
+	if n.IsLeaf() {
+		if n.Label != "" {
+			return n.Label
+		}
+		return "."
+	}
+	op := "|"
+	if n.Split == Down {
+		op = "/"
+	}
+	return fmt.Sprintf("(%s %s %s %.2f)", shape(n.First), op, shape(n.Second), n.Ratio)
+}
+
+func TestParseTmux(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"list-windows output",
+			"ef44,159x48,0,0{79x48,0,0,1,79x48,80,0[79x24,80,0,2,79x23,80,25,3]}",
+			"(. | (. / . 0.50) 0.50)",
+		},
+		{
+			"three near-equal columns",
+			"a376,159x48,0,0[159x24,0,0,1,159x23,0,25{53x23,0,25,2,52x23,54,25,3,52x23,107,25,4}]",
+			"(. / (. | (. | . 0.50) 0.33) 0.50)",
+		},
+		{
+			"uneven split keeps its proportions",
+			"92fa,159x48,0,0{39x48,0,0,1,119x48,40,0,2}",
+			"(. | . 0.25)",
+		},
+		{
+			"no checksum",
+			"159x48,0,0{79x48,0,0,1,79x48,80,0,2}",
+			"(. | . 0.50)",
+		},
+		{
+			"old format without pane IDs",
+			"159x48,0,0{79x48,0,0,79x48,80,0}",
+			"(. | . 0.50)",
+		},
+		{
+			"old format nested",
+			"159x48,0,0[159x24,0,0,159x23,0,25{79x23,0,25,79x23,80,25}]",
+			"(. / (. | . 0.50) 0.50)",
+		},
+		{
+			"single pane",
+			"  80x24,0,0,5\n",
+			".",
+		},
+		{
+			"containers nested both ways",
+			"200x50,0,0{100x50,0,0[100x10,0,0,1,100x39,0,11{50x39,0,11,2,49x39,51,11,3}],99x50,101,0,4}",
+			"((. / (. | . 0.50) 0.20) | . 0.50)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ParseTmux(tt.in)
+			if err != nil {
+				t.Fatalf("ParseTmux(%q): %v", tt.in, err)
+			}
+			if got := shape(tree); got != tt.want {
+				t.Errorf("ParseTmux(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTmuxErrors(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"bad checksum", "ef45,159x48,0,0{79x48,0,0,1,79x48,80,0[79x24,80,0,2,79x23,80,25,3]}", "checksum is ef45"},
+		{"checksum not hex", "zz12,80x24,0,0,1", "bad checksum"},
+		{"unclosed container", "159x48,0,0{79x48,0,0,1,79x48,80,0,2", "expected '}', got end of layout"},
+		{"mismatched brackets", "159x48,0,0{79x48,0,0,1,79x48,80,0,2]", "expected '}'"},
+		{"missing height", "159x,0,0", "expected a number"},
+		{"trailing text", "80x24,0,0,1}", "unexpected '}'"},
+		{"empty", "", "expected a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTmux(tt.in)
+			if err == nil {
+				t.Fatalf("ParseTmux(%q) succeeded, want an error", tt.in)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTmux(%q) error = %q, want it to contain %q", tt.in, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTmuxNearEqualNeedsNoResize(t *testing.T) {
+	tree, err := ParseTmux("159x48,0,0[159x24,0,0,1,159x23,0,25]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range tree.Steps() {
+		if step.Action == ActionResize {
+			t.Errorf("24/23 rows produced %+v, want no resize", step)
+		}
+	}
+
+	tree, err = ParseTmux("159x48,0,0[159x30,0,0,1,159x17,0,31]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resized := false
+	for _, step := range tree.Steps() {
+		resized = resized || step.Action == ActionResize
+	}
+	if !resized {
+		t.Error("30/17 rows produced no resize step")
+	}
+}
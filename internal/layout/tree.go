@@ -0,0 +1,421 @@
+package layout
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Reference window size in pixels used to turn split ratios into
+// resize_split amounts. Ghostty only resizes by pixels, so ratios are
+// approximate on windows of other sizes.
+const (
+	ReferenceWidthPx  = 1600
+	ReferenceHeightPx = 1000
+)
+
+// Node is a pane tree. A leaf is a single pane; an inner node splits its
+// region between First and Second, with First getting Ratio of the space.
+type Node struct {
+	Split  Direction
+	Ratio  float64
+	First  *Node
+	Second *Node
+	Label  string
+}
+
+type Rect struct {
+	X, Y, W, H float64
+}
+
+func NewTree() *Node {
+	return &Node{}
+}
+
+func (n *Node) IsLeaf() bool {
+	return n.First == nil
+}
+
+func (n *Node) Leaves() []*Node {
+	if n.IsLeaf() {
+		return []*Node{n}
+	}
+	return append(n.First.Leaves(), n.Second.Leaves()...)
+}
+
+func (n *Node) PaneCount() int {
+	return len(n.Leaves())
+}
+
+// SplitLeaf turns leaf into a split with the old pane first and a new pane
+// second, and returns the new pane.
+func (n *Node) SplitLeaf(dir Direction) *Node {
+	old := &Node{Label: n.Label}
+	fresh := &Node{}
+	n.Split = dir
+	n.Ratio = 0.5
+	n.First = old
+	n.Second = fresh
+	n.Label = ""
+	return fresh
+}
+
+func (n *Node) Parent(child *Node) *Node {
+	if n.IsLeaf() {
+		return nil
+	}
+	if n.First == child || n.Second == child {
+		return n
+	}
+	if p := n.First.Parent(child); p != nil {
+		return p
+	}
+	return n.Second.Parent(child)
+}
+
+// Remove closes leaf, letting its sibling take over the parent's space. It
+// returns the pane that should receive focus.
+func (n *Node) Remove(leaf *Node) *Node {
+	parent := n.Parent(leaf)
+	if parent == nil {
+		return leaf
+	}
+	sibling := parent.First
+	if sibling == leaf {
+		sibling = parent.Second
+	}
+	*parent = *sibling
+	return parent.Leaves()[0]
+}
+
+// Rects returns the region of every leaf in a unit square.
+func (n *Node) Rects() map[*Node]Rect {
+	rects := map[*Node]Rect{}
+	n.rects(Rect{W: 1, H: 1}, rects)
+	return rects
+}
+
+func (n *Node) rects(r Rect, out map[*Node]Rect) {
+	if n.IsLeaf() {
+		out[n] = r
+		return
+	}
+	a, b := n.divide(r)
+	n.First.rects(a, out)
+	n.Second.rects(b, out)
+}
+
+func (n *Node) divide(r Rect) (Rect, Rect) {
+	if n.Split == Right {
+		w := r.W * n.Ratio
+		return Rect{r.X, r.Y, w, r.H}, Rect{r.X + w, r.Y, r.W - w, r.H}
+	}
+	h := r.H * n.Ratio
+	return Rect{r.X, r.Y, r.W, h}, Rect{r.X, r.Y + h, r.W, r.H - h}
+}
+
+// Neighbor finds the pane next to leaf in the given direction, preferring
+// the closest one that overlaps it on the other axis.
+func (n *Node) Neighbor(leaf *Node, dir Direction) *Node {
+	rects := n.Rects()
+	from := rects[leaf]
+	cx, cy := from.X+from.W/2, from.Y+from.H/2
+	const eps = 1e-9
+
+	var best *Node
+	bestDist := math.Inf(1)
+	for _, other := range n.Leaves() {
+		if other == leaf {
+			continue
+		}
+		r := rects[other]
+		var ok bool
+		var dist float64
+		switch dir {
+		case Left:
+			ok = r.X+r.W <= from.X+eps && r.Y < from.Y+from.H-eps && r.Y+r.H > from.Y+eps
+			dist = from.X - (r.X + r.W)
+		case Right:
+			ok = r.X >= from.X+from.W-eps && r.Y < from.Y+from.H-eps && r.Y+r.H > from.Y+eps
+			dist = r.X - (from.X + from.W)
+		case Up:
+			ok = r.Y+r.H <= from.Y+eps && r.X < from.X+from.W-eps && r.X+r.W > from.X+eps
+			dist = from.Y - (r.Y + r.H)
+		case Down:
+			ok = r.Y >= from.Y+from.H-eps && r.X < from.X+from.W-eps && r.X+r.W > from.X+eps
+			dist = r.Y - (from.Y + from.H)
+		}
+		if !ok {
+			continue
+		}
+		dist += math.Abs(r.X+r.W/2-cx)*1e-3 + math.Abs(r.Y+r.H/2-cy)*1e-3
+		if dist < bestDist {
+			best = other
+			bestDist = dist
+		}
+	}
+	return best
+}
+
+// Steps generates the keystroke steps that build the tree in Ghostty,
+// ending with focus on the first pane. Every split is followed by its
+// subtree on the new side, then one focus back to the original pane.
+func (n *Node) Steps() []LayoutStep {
+	var steps []LayoutStep
+	n.steps(Rect{W: 1, H: 1}, &steps)
+	return steps
+}
+
+func (n *Node) steps(r Rect, steps *[]LayoutStep) {
+	if n.IsLeaf() {
+		return
+	}
+
+	*steps = append(*steps, LayoutStep{Action: ActionSplit, Direction: n.Split})
+	if resize, ok := n.resizeStep(r); ok {
+		*steps = append(*steps, resize)
+	}
+
+	a, b := n.divide(r)
+	n.Second.steps(b, steps)
+	*steps = append(*steps, LayoutStep{Action: ActionFocus, Direction: Previous})
+	n.First.steps(a, steps)
+}
+
+func (n *Node) resizeStep(r Rect) (LayoutStep, bool) {
+	size := r.W * ReferenceWidthPx
+	grow, shrink := Right, Left
+	if n.Split == Down {
+		size = r.H * ReferenceHeightPx
+		grow, shrink = Down, Up
+	}
+
+	px := int(math.Round((n.Ratio - 0.5) * size))
+	switch {
+	case px > 0:
+		return LayoutStep{Action: ActionResize, Direction: grow, Amount: px}, true
+	case px < 0:
+		return LayoutStep{Action: ActionResize, Direction: shrink, Amount: -px}, true
+	}
+	return LayoutStep{}, false
+}
+
+// LeafLabel returns the label shown for leaf i, defaulting to A, B, C...
+func LeafLabel(leaf *Node, i int) string {
+	if leaf.Label != "" {
+		return leaf.Label
+	}
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// Canvas is a box-drawing rendering of a tree. Owner maps each interior
+// cell to the leaf that covers it.
+type Canvas struct {
+	Lines [][]rune
+	Owner [][]*Node
+}
+
+func (c Canvas) Strings() []string {
+	lines := make([]string, len(c.Lines))
+	for i, l := range c.Lines {
+		lines[i] = string(l)
+	}
+	return lines
+}
+
+const (
+	edgeUp = 1 << iota
+	edgeDown
+	edgeLeft
+	edgeRight
+)
+
+var boxChars = map[int]rune{
+	edgeLeft | edgeRight:                     '─',
+	edgeUp | edgeDown:                        '│',
+	edgeDown | edgeRight:                     '┌',
+	edgeDown | edgeLeft:                      '┐',
+	edgeUp | edgeRight:                       '└',
+	edgeUp | edgeLeft:                        '┘',
+	edgeUp | edgeDown | edgeRight:            '├',
+	edgeUp | edgeDown | edgeLeft:             '┤',
+	edgeDown | edgeLeft | edgeRight:          '┬',
+	edgeUp | edgeLeft | edgeRight:            '┴',
+	edgeUp | edgeDown | edgeLeft | edgeRight: '┼',
+	edgeLeft:                                 '─',
+	edgeRight:                                '─',
+	edgeUp:                                   '│',
+	edgeDown:                                 '│',
+}
+
+// Render draws the tree into a width x height box, labelling each pane.
+func (n *Node) Render(width, height int) Canvas {
+	edges := make([][]int, height)
+	owner := make([][]*Node, height)
+	for y := range edges {
+		edges[y] = make([]int, width)
+		owner[y] = make([]*Node, width)
+	}
+
+	hline := func(y, x0, x1 int) {
+		for x := x0; x <= x1; x++ {
+			if x > x0 {
+				edges[y][x] |= edgeLeft
+			}
+			if x < x1 {
+				edges[y][x] |= edgeRight
+			}
+		}
+	}
+	vline := func(x, y0, y1 int) {
+		for y := y0; y <= y1; y++ {
+			if y > y0 {
+				edges[y][x] |= edgeUp
+			}
+			if y < y1 {
+				edges[y][x] |= edgeDown
+			}
+		}
+	}
+
+	hline(0, 0, width-1)
+	hline(height-1, 0, width-1)
+	vline(0, 0, height-1)
+	vline(width-1, 0, height-1)
+
+	leaves := n.Leaves()
+	index := map[*Node]int{}
+	for i, l := range leaves {
+		index[l] = i
+	}
+
+	type box struct{ x0, y0, x1, y1 int }
+	var draw func(node *Node, b box)
+	draw = func(node *Node, b box) {
+		if node.IsLeaf() {
+			for y := b.y0 + 1; y < b.y1; y++ {
+				for x := b.x0 + 1; x < b.x1; x++ {
+					owner[y][x] = node
+				}
+			}
+			return
+		}
+		if node.Split == Right {
+			x := b.x0 + int(math.Round(float64(b.x1-b.x0)*node.Ratio))
+			x = clamp(x, b.x0+2, b.x1-2)
+			vline(x, b.y0, b.y1)
+			draw(node.First, box{b.x0, b.y0, x, b.y1})
+			draw(node.Second, box{x, b.y0, b.x1, b.y1})
+			return
+		}
+		y := b.y0 + int(math.Round(float64(b.y1-b.y0)*node.Ratio))
+		y = clamp(y, b.y0+2, b.y1-2)
+		hline(y, b.x0, b.x1)
+		draw(node.First, box{b.x0, b.y0, b.x1, y})
+		draw(node.Second, box{b.x0, y, b.x1, b.y1})
+	}
+	draw(n, box{0, 0, width - 1, height - 1})
+
+	lines := make([][]rune, height)
+	for y := range lines {
+		lines[y] = make([]rune, width)
+		for x := range lines[y] {
+			if r, ok := boxChars[edges[y][x]]; ok {
+				lines[y][x] = r
+			} else {
+				lines[y][x] = ' '
+			}
+		}
+	}
+
+	for _, leaf := range leaves {
+		x0, y0, x1, y1 := width, height, -1, -1
+		for y := range owner {
+			for x, o := range owner[y] {
+				if o == leaf {
+					x0, y0 = min(x0, x), min(y0, y)
+					x1, y1 = max(x1, x), max(y1, y)
+				}
+			}
+		}
+		if x1 < 0 {
+			continue
+		}
+		label := []rune(LeafLabel(leaf, index[leaf]))
+		w := x1 - x0 + 1
+		if len(label) > w {
+			label = label[:w]
+		}
+		y := y0 + (y1-y0)/2
+		x := x0 + (w-len(label))/2
+		copy(lines[y][x:], label)
+	}
+
+	return Canvas{Lines: lines, Owner: owner}
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		return (lo + hi) / 2
+	}
+	return max(lo, min(v, hi))
+}
+
+// PreviewSize picks a card-sized preview box for the tree, growing it a
+// little for trees with many panes so that labels stay readable.
+func (n *Node) PreviewSize() (int, int) {
+	cols, rows := n.span()
+	width := max(13, cols*4+1)
+	height := max(5, min(rows*2+1, 7))
+	return width, height
+}
+
+func (n *Node) span() (int, int) {
+	if n.IsLeaf() {
+		return 1, 1
+	}
+	ac, ar := n.First.span()
+	bc, br := n.Second.span()
+	if n.Split == Right {
+		return ac + bc, max(ar, br)
+	}
+	return max(ac, bc), ar + br
+}
+
+// FromTree builds a layout from a pane tree. Ghostty halves the focused
+// pane on every split, which already matches the tree, so equalize is
+// turned off to keep the ratios instead of evening out all panes.
+func FromTree(name string, tree *Node) Layout {
+	w, h := tree.PreviewSize()
+	l := Layout{
+		ID:          Slugify(name),
+		Name:        name,
+		Description: treeDescription(tree),
+		Preview:     tree.Render(w, h).Strings(),
+		Steps:       tree.Steps(),
+		PaneCount:   tree.PaneCount(),
+	}
+	off := false
+	l.Equalize = &off
+	return l
+}
+
+func treeDescription(tree *Node) string {
+	var labels []string
+	for i, leaf := range tree.Leaves() {
+		if leaf.Label != "" {
+			labels = append(labels, LeafLabel(leaf, i))
+		}
+	}
+	desc := "Single pane"
+	if n := tree.PaneCount(); n > 1 {
+		desc = fmt.Sprintf("%d panes", n)
+	}
+	if len(labels) > 0 {
+		desc += ": " + strings.Join(labels, ", ")
+	}
+	return desc
+}
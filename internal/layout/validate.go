@@ -0,0 +1,125 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CountPanes is the number of panes the steps leave in the tab they start
+// in, starting from one. Panes made after a step opens a tab or window are
+// in that tab instead and aren't counted.
+func CountPanes(steps []LayoutStep) int {
+	panes := 1
+	for _, step := range steps {
+		if step.OpensTab() {
+			break
+		}
+		if step.CreatesPane() {
+			panes++
+		}
+	}
+	return panes
+}
+
+// Validate walks a layout's steps the way the engine would run them and
+// returns a description of each problem found, prefixed with the step
+// number where there is one. A nil result means the layout can be applied,
+// given the Ghostty keybindings it needs.
+func Validate(l Layout) []string {
+	var problems []string
+	report := func(i int, format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		if i >= 0 {
+			msg = fmt.Sprintf("step %d: %s", i+1, msg)
+		}
+		problems = append(problems, msg)
+	}
+
+	if l.ID == "" {
+		report(-1, "id is empty")
+	}
+	if l.Name == "" {
+		report(-1, "name is empty")
+	}
+
+	// panes counts the panes of the tab the steps are in; the starting tab's
+	// count is kept in origin once a step opens another.
+	panes, origin := 1, 0
+	for i, step := range l.Steps {
+		switch step.Action {
+		case ActionSplit:
+			if !isSide(step.Direction) {
+				report(i, "split direction must be right, left, down or up, got '%s'", step.Direction)
+			}
+		case ActionFocus:
+			if !isSide(step.Direction) && step.Direction != Previous && step.Direction != Next {
+				report(i, "focus direction must be right, left, down, up, previous or next, got '%s'", step.Direction)
+			} else if panes == 1 {
+				report(i, "focus %s runs before there is a split to move to", step.Direction)
+			}
+		case ActionResize:
+			if !isSide(step.Direction) {
+				report(i, "resize direction must be right, left, down or up, got '%s'", step.Direction)
+			}
+			if step.Amount <= 0 {
+				report(i, "resize amount must be a positive number of pixels")
+			}
+		case ActionDelay:
+			if step.DelayMs <= 0 {
+				report(i, "delay_ms must be positive")
+			}
+		case ActionGhostty:
+			name, _, _ := strings.Cut(step.Ghostty, ":")
+			if step.Ghostty == "" {
+				report(i, "ghostty step has no action")
+			} else if closeActions[name] {
+				report(i, "ghostty %s closes panes, which pane counts, undo and reset can't follow", name)
+			}
+		case ActionType:
+			if step.Text == "" {
+				report(i, "type step has no text")
+			}
+		case ActionEqualize:
+		default:
+			report(i, "unknown action '%s'", step.Action)
+		}
+		if step.OpensTab() {
+			if origin == 0 {
+				origin = panes
+			}
+			panes = 1
+		}
+		if step.CreatesPane() {
+			panes++
+		}
+	}
+	if origin == 0 {
+		origin = panes
+	}
+
+	if l.FinalFocus != "" && !isSide(l.FinalFocus) && l.FinalFocus != Previous && l.FinalFocus != Next {
+		report(-1, "final_focus must be right, left, down, up, previous or next, got '%s'", l.FinalFocus)
+	}
+	if l.PaneCount != 0 && l.PaneCount != origin {
+		report(-1, "pane_count is %d but the steps create %d panes", l.PaneCount, origin)
+	}
+	return problems
+}
+
+// closeActions are Ghostty actions a layout step may not run: every pane a
+// layout leaves must be one it split off, so that undo and reset know what
+// to close.
+var closeActions = map[string]bool{
+	"close_surface":     true,
+	"close_tab":         true,
+	"close_window":      true,
+	"close_all_windows": true,
+}
+
+func isSide(d Direction) bool {
+	switch d {
+	case Right, Left, Down, Up:
+		return true
+	}
+	return false
+}
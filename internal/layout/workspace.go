@@ -0,0 +1,29 @@
+package layout
+
+// Workspace is a set of tabs brought up together by `tyle up`.
+type Workspace struct {
+	ID   string
+	Name string
+	Tabs []Tab
+}
+
+// Tab is one tab of a workspace: the layout to apply in it and the
+// commands to run in its panes. Commands are in pane creation order: the
+// first runs in the tab's starting pane and each following one in the pane
+// made by the next split.
+type Tab struct {
+	Title    string
+	Layout   Layout
+	Commands []string
+	// Window opens the tab in a new window instead of a new tab.
+	Window bool
+}
+
+// Panes is the total number of panes the workspace creates.
+func (w Workspace) Panes() int {
+	total := 0
+	for _, t := range w.Tabs {
+		total += max(t.Layout.PaneCount, 1)
+	}
+	return total
+}
@@ -0,0 +1,48 @@
+// Package output writes command results as JSON, YAML or TOML for scripts.
+// The table format is left to each command.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Format string
+
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	TOML  Format = "toml"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Table, JSON, YAML, TOML:
+		return f, nil
+	case "":
+		return Table, nil
+	}
+	return "", fmt.Errorf("unknown output format '%s' — use table, json, yaml or toml", s)
+}
+
+// Write encodes v in a machine-readable format. Field names come from json
+// tags for JSON and YAML and from toml tags for TOML, so v should carry
+// both. TOML needs v to be a struct or map at the top level.
+func Write(w io.Writer, f Format, v any) error {
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		_, err := io.WriteString(w, encodeYAML(v))
+		return err
+	case TOML:
+		return toml.NewEncoder(w).Encode(v)
+	}
+	return fmt.Errorf("format '%s' has no encoder", f)
+}
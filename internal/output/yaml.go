@@ -0,0 +1,150 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// encodeYAML is a small YAML emitter for the plain data commands print:
+// structs, maps with string keys, slices and scalars. Struct fields are
+// named and omitted following their json tags.
+func encodeYAML(v any) string {
+	return strings.Join(yamlLines(reflect.ValueOf(v)), "\n") + "\n"
+}
+
+// yamlLines renders v as block YAML. A scalar or empty collection is a
+// single line that fits after "key: " or "- ".
+func yamlLines(v reflect.Value) []string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []string{"null"}
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var lines []string
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, omitEmpty := jsonName(field)
+			if name == "-" || (omitEmpty && v.Field(i).IsZero()) {
+				continue
+			}
+			lines = append(lines, yamlEntry(name, v.Field(i))...)
+		}
+		if lines == nil {
+			return []string{"{}"}
+		}
+		return lines
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return []string{"{}"}
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		var lines []string
+		for _, k := range keys {
+			lines = append(lines, yamlEntry(fmt.Sprint(k), v.MapIndex(k))...)
+		}
+		return lines
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return []string{"[]"}
+		}
+		var lines []string
+		for i := 0; i < v.Len(); i++ {
+			item := yamlLines(v.Index(i))
+			lines = append(lines, "- "+item[0])
+			for _, line := range item[1:] {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+
+	case reflect.String:
+		return []string{yamlString(v.String())}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'g', -1, 64)}
+	}
+	return []string{yamlString(fmt.Sprint(v.Interface()))}
+}
+
+// yamlEntry renders "key: value", putting block values on indented lines
+// below the key.
+func yamlEntry(key string, v reflect.Value) []string {
+	value := yamlLines(v)
+	if len(value) == 1 && !isBlock(v) {
+		return []string{yamlString(key) + ": " + value[0]}
+	}
+	lines := []string{yamlString(key) + ":"}
+	for _, line := range value {
+		lines = append(lines, "  "+line)
+	}
+	return lines
+}
+
+func isBlock(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return yamlLines(v)[0] != "{}"
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return v.Len() > 0
+	}
+	return false
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// yamlString leaves simple words unquoted and double-quotes everything
+// else, including words YAML would read as booleans, nulls or numbers.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "", "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	for i, r := range s {
+		plain := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_' ||
+			i > 0 && (r >= '0' && r <= '9' || strings.ContainsRune("-./+", r))
+		if !plain {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package output
+
+import "testing"
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"dev", "dev"},
+		{"two-columns", "two-columns"},
+		{"main_v2.1", "main_v2.1"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"No", `"No"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"42", `"42"`},
+		{"1e3", `"1e3"`},
+		{"-dev", `"-dev"`},
+		{"2x2", `"2x2"`},
+		{"Main + Side", `"Main + Side"`},
+		{"key: value", `"key: value"`},
+		{"a #comment", `"a #comment"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"line\nbreak", `"line\nbreak"`},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	type step struct {
+		Action    string `json:"action"`
+		Direction string `json:"direction,omitempty"`
+	}
+	type layout struct {
+		ID      string   `json:"id"`
+		Panes   int      `json:"panes"`
+		Preview []string `json:"preview,omitempty"`
+		Steps   []step   `json:"steps"`
+		Secret  string   `json:"-"`
+		private string
+	}
+
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{"scalar", 3, "3\n"},
+		{"empty slice", []string{}, "[]\n"},
+		{"nil slice", []string(nil), "[]\n"},
+		{"empty map", map[string]int{}, "{}\n"},
+		{"nil pointer", (*layout)(nil), "null\n"},
+		{"string slice", []string{"a", "yes"}, "- a\n- \"yes\"\n"},
+		{"nested slices", [][]int{{1, 2}, {}, {3}}, "- - 1\n  - 2\n- []\n- - 3\n"},
+		{
+			"map sorted by key",
+			map[string]any{"b": 1, "a": []string{"x"}, "c": map[string]bool{}},
+			"a:\n  - x\nb: 1\nc: {}\n",
+		},
+		{
+			"nested maps",
+			map[string]map[string]float64{"ratio": {"first": 0.5, "second": 0.25}},
+			"ratio:\n  first: 0.5\n  second: 0.25\n",
+		},
+		{
+			"struct with omitted fields",
+			layout{ID: "dev", Panes: 2, Steps: []step{{Action: "split", Direction: "right"}, {Action: "equalize"}}, Secret: "x", private: "y"},
+			"id: dev\npanes: 2\nsteps:\n  - action: split\n    direction: right\n  - action: equalize\n",
+		},
+		{
+			"struct with empty array",
+			layout{ID: "one", Panes: 1, Steps: []step{}},
+			"id: one\npanes: 1\nsteps: []\n",
+		},
+		{
+			"slice of structs needing quotes",
+			[]layout{{ID: "2x2", Panes: 4, Preview: []string{"┌─┐", ""}}},
+			"- id: \"2x2\"\n  panes: 4\n  preview:\n    - \"┌─┐\"\n    - \"\"\n  steps: []\n",
+		},
+		{"empty struct", struct{}{}, "{}\n"},
+		{"interface holding nil", map[string]any{"x": nil}, "x: null\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeYAML(tt.in); got != tt.want {
+				t.Errorf("encodeYAML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/atkntepe/tyle/internal/engine"
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// ApplyFunc applies a layout, reporting each step through progress and
+// stopping between steps once ctx is cancelled.
+type ApplyFunc func(ctx context.Context, l layout.Layout, progress func(engine.Event)) error
+
+type applyEventMsg engine.Event
+
+type applyDoneMsg struct {
+	err error
+}
+
+type applyState struct {
+	layout     layout.Layout
+	steps      []layout.LayoutStep
+	status     map[int]engine.StepStatus
+	current    int
+	panes      int
+	events     chan tea.Msg
+	cancel     context.CancelFunc
+	cancelling bool
+	done       bool
+	err        error
+}
+
+// typesText reports whether applying l types into a pane. The picker runs
+// in the pane layouts are applied from and reads its keys, so text typed
+// there while it shows progress would land in the picker instead of the
+// shell.
+func typesText(l layout.Layout, opts engine.Options) bool {
+	if len(opts.Commands) > 0 {
+		return true
+	}
+	for _, step := range l.Steps {
+		if step.Action == layout.ActionType {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForApply(events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+func (m Model) startApply(l layout.Layout) (Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan tea.Msg, 16)
+
+	m.applying = &applyState{
+		layout:  l,
+		steps:   engine.ResolveSteps(l, m.execute),
+		status:  map[int]engine.StepStatus{},
+		current: -1,
+		panes:   1,
+		events:  events,
+		cancel:  cancel,
+	}
+
+	apply := m.apply
+	go func() {
+		err := apply(ctx, l, func(e engine.Event) {
+			events <- applyEventMsg(e)
+		})
+		events <- applyDoneMsg{err: err}
+	}()
+
+	return m, waitForApply(events)
+}
+
+func (m Model) updateApply(msg tea.Msg) (tea.Model, tea.Cmd) {
+	a := *m.applying
+	m.applying = &a
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case applyEventMsg:
+		a.status = copyStatus(a.status)
+		a.status[msg.Index] = msg.Status
+		a.current = msg.Index
+		a.panes = msg.Panes
+		return m, waitForApply(a.events)
+
+	case applyDoneMsg:
+		// The picker quits whether or not the layout applied: once a split
+		// has moved focus to another pane, no key would reach it to close
+		// it. The caller reports ApplyErr.
+		a.done = true
+		a.err = msg.err
+		a.cancel()
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		// Keys only arrive while the picker's pane has focus, so cancelling
+		// works until the first split moves focus away.
+		action, _ := m.keys.lookup(msg.String())
+		if msg.Type == tea.KeyCtrlC || action == ActionBack || action == ActionQuit {
+			a.cancelling = true
+			a.cancel()
+		}
+	}
+
+	return m, nil
+}
+
+func copyStatus(status map[int]engine.StepStatus) map[int]engine.StepStatus {
+	out := make(map[int]engine.StepStatus, len(status)+1)
+	for k, v := range status {
+		out[k] = v
+	}
+	return out
+}
+
+func (m Model) applyView() string {
+	a := m.applying
+	header := headerStyle.Render("⊞ tyle  " + filterStyle.Render("Applying "+a.layout.Name))
+
+	lines := []string{
+		detailDimStyle.Render(fmt.Sprintf("Panes: %d of %d", a.panes, a.layout.PaneCount)),
+		"",
+	}
+
+	available := m.height - headerHeight - helpHeight - 4
+	first, last := 0, len(a.steps)
+	if available > 0 && len(a.steps) > available {
+		first = max(0, min(a.current-available/2, len(a.steps)-available))
+		last = first + available
+	}
+
+	for i := first; i < last; i++ {
+		text := fmt.Sprintf("%2d. %s", i+1, a.steps[i])
+		status, seen := a.status[i]
+		switch {
+		case seen && status == engine.StepDone:
+			lines = append(lines, detailTextStyle.Render("✓ "+text))
+		case seen && status == engine.StepFailed:
+			lines = append(lines, errorStepStyle.Render("✗ "+text))
+		case seen && status == engine.StepStarted:
+			lines = append(lines, detailTitleStyle.Render("▸ "+text))
+		default:
+			lines = append(lines, detailDimStyle.Render("  "+text))
+		}
+	}
+
+	body := lipgloss.NewStyle().Padding(0, 2).Render(strings.Join(lines, "\n"))
+
+	var help string
+	switch {
+	case a.done && errors.Is(a.err, context.Canceled):
+		help = helpStyle.Render(warnStyle.Render("Cancelled"))
+	case a.done && a.err != nil:
+		help = helpStyle.Render(warnStyle.Render(a.err.Error()))
+	case a.cancelling:
+		help = helpStyle.Render("Cancelling after the current step...")
+	default:
+		help = helpStyle.Render(strings.TrimRight(keyItem(m.keys.label(ActionBack), "cancel"), " "))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, help)
+}
+
+// Applied reports whether the picker applied the selected layout itself.
+// When it didn't, the caller applies it once the picker has quit and
+// released the terminal.
+func (m Model) Applied() bool {
+	return m.applying != nil
+}
+
+// ApplyErr returns the error that stopped the apply, if any.
+func (m Model) ApplyErr() error {
+	if m.applying == nil {
+		return nil
+	}
+	return m.applying.err
+}
@@ -0,0 +1,349 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+type builderMode int
+
+const (
+	builderNormal builderMode = iota
+	builderLabel
+	builderName
+	builderOverwrite
+)
+
+const ratioStep = 0.05
+
+// Builder is an interactive editor for pane trees. It starts from a single
+// pane; splitting, focusing, resizing and labelling panes updates the
+// preview live, and saving turns the tree into a custom layout.
+type Builder struct {
+	tree  *layout.Node
+	focus *layout.Node
+
+	mode   builderMode
+	input  string
+	status string
+
+	save     SaveFunc
+	keys     Keymap
+	pending  *layout.Layout
+	saved    *layout.Layout
+	done     bool
+	embedded bool
+
+	width  int
+	height int
+}
+
+// SaveFunc persists a layout built in the builder. Without replace it
+// returns ErrLayoutExists for an ID that is already taken, and the builder
+// asks before calling it again with replace set.
+type SaveFunc func(l layout.Layout, replace bool) error
+
+// ErrLayoutExists is returned by a SaveFunc or Store.SaveLayout when the
+// layout's ID is taken and replacing it wasn't asked for.
+var ErrLayoutExists = errors.New("a layout with that ID already exists")
+
+// NewBuilder creates a standalone builder that quits once the layout is
+// saved or the user cancels. save persists the finished layout; keys
+// supplies the picker's select, back and quit keys, the default keymap's
+// if nil.
+func NewBuilder(save SaveFunc, keys Keymap) Builder {
+	if keys == nil {
+		keys = DefaultKeymap()
+	}
+	tree := layout.NewTree()
+	return Builder{tree: tree, focus: tree, save: save, keys: keys}
+}
+
+func newEmbeddedBuilder(save SaveFunc, keys Keymap, width, height int) Builder {
+	b := NewBuilder(save, keys)
+	b.embedded = true
+	b.width = width
+	b.height = height
+	return b
+}
+
+func (b Builder) Init() tea.Cmd {
+	return nil
+}
+
+func (b Builder) finish() (Builder, tea.Cmd) {
+	b.done = true
+	if b.embedded {
+		return b, nil
+	}
+	return b, tea.Quit
+}
+
+func (b Builder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return b.update(msg)
+}
+
+func (b Builder) update(msg tea.Msg) (Builder, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		b.width = msg.Width
+		b.height = msg.Height
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return b.finish()
+		}
+		if b.mode == builderOverwrite {
+			return b.updateOverwrite(msg)
+		}
+		if b.mode != builderNormal {
+			return b.updateInput(msg)
+		}
+
+		b.status = ""
+		switch action, _ := b.keys.lookup(msg.String()); action {
+		case ActionBack, ActionQuit:
+			return b.finish()
+		case ActionSelect:
+			return b.startSave(), nil
+		}
+
+		switch msg.String() {
+
+		case "r", "|":
+			b.focus = b.focus.SplitLeaf(layout.Right)
+
+		case "d", "_":
+			b.focus = b.focus.SplitLeaf(layout.Down)
+
+		case "x":
+			if b.tree.IsLeaf() {
+				b.status = "Can't close the last pane"
+				break
+			}
+			b.focus = b.tree.Remove(b.focus)
+
+		case "left", "h":
+			b.moveFocus(layout.Left)
+		case "right", "l":
+			b.moveFocus(layout.Right)
+		case "up", "k":
+			b.moveFocus(layout.Up)
+		case "down", "j":
+			b.moveFocus(layout.Down)
+
+		case "tab":
+			b.cycleFocus(1)
+		case "shift+tab":
+			b.cycleFocus(-1)
+
+		case "+", "=":
+			b.resize(ratioStep)
+		case "-":
+			b.resize(-ratioStep)
+		case "0":
+			if p := b.tree.Parent(b.focus); p != nil {
+				p.Ratio = 0.5
+			}
+
+		case "n":
+			b.mode = builderLabel
+			b.input = b.focus.Label
+
+		case "s", "ctrl+s":
+			return b.startSave(), nil
+		}
+	}
+
+	return b, nil
+}
+
+// startSave asks for the layout's name, once there is something to save.
+func (b Builder) startSave() Builder {
+	if b.tree.IsLeaf() {
+		b.status = "Split at least once before saving"
+		return b
+	}
+	b.mode = builderName
+	b.input = ""
+	return b
+}
+
+// updateInput handles keys while typing a label or name. As in the
+// picker's filter, letters are always text.
+func (b Builder) updateInput(msg tea.KeyMsg) (Builder, tea.Cmd) {
+	action, bound := b.keys.lookup(msg.String())
+	if isTextKey(msg) {
+		bound = false
+	}
+	switch {
+
+	case bound && action == ActionBack:
+		b.mode = builderNormal
+		b.input = ""
+
+	case bound && action == ActionSelect:
+		value := strings.TrimSpace(b.input)
+		if b.mode == builderLabel {
+			b.focus.Label = value
+			b.mode = builderNormal
+			b.input = ""
+			break
+		}
+
+		if layout.Slugify(value) == "" {
+			b.status = "Name must contain letters or digits"
+			break
+		}
+		return b.saveLayout(layout.FromTree(value, b.tree), false)
+
+	case msg.Type == tea.KeyBackspace:
+		if b.input != "" {
+			runes := []rune(b.input)
+			b.input = string(runes[:len(runes)-1])
+		}
+
+	case isTextKey(msg):
+		b.input += string(msg.Runes)
+	}
+
+	return b, nil
+}
+
+func (b *Builder) moveFocus(dir layout.Direction) {
+	if next := b.tree.Neighbor(b.focus, dir); next != nil {
+		b.focus = next
+	}
+}
+
+func (b *Builder) cycleFocus(delta int) {
+	leaves := b.tree.Leaves()
+	for i, l := range leaves {
+		if l == b.focus {
+			b.focus = leaves[(i+delta+len(leaves))%len(leaves)]
+			return
+		}
+	}
+}
+
+// resize grows the focused pane by delta of its parent split.
+func (b *Builder) resize(delta float64) {
+	parent := b.tree.Parent(b.focus)
+	if parent == nil {
+		return
+	}
+	if parent.Second == b.focus {
+		delta = -delta
+	}
+	parent.Ratio = max(0.1, min(0.9, parent.Ratio+delta))
+}
+
+func (b Builder) updateOverwrite(msg tea.KeyMsg) (Builder, tea.Cmd) {
+	action, _ := b.keys.lookup(msg.String())
+	switch {
+	case msg.String() == "y" || msg.String() == "Y":
+		l := *b.pending
+		b.pending = nil
+		b.mode = builderName
+		return b.saveLayout(l, true)
+	case msg.String() == "n" || msg.String() == "N" || action == ActionBack || action == ActionQuit:
+		b.pending = nil
+		b.mode = builderName
+		b.status = "Pick another name"
+	}
+	return b, nil
+}
+
+func (b Builder) saveLayout(l layout.Layout, replace bool) (Builder, tea.Cmd) {
+	if b.save != nil {
+		err := b.save(l, replace)
+		if errors.Is(err, ErrLayoutExists) {
+			b.pending = &l
+			b.mode = builderOverwrite
+			return b, nil
+		}
+		if err != nil {
+			b.status = fmt.Sprintf("Failed to save: %v", err)
+			return b, nil
+		}
+	}
+	b.saved = &l
+	return b.finish()
+}
+
+func (b Builder) View() string {
+	header := headerStyle.Render("⊞ tyle  " + filterStyle.Render("new layout"))
+
+	width := max(21, min(b.width-4, 64))
+	height := max(7, min(b.height-headerHeight-helpHeight-4, 21))
+	canvas := b.tree.Render(width, height)
+
+	var lines []string
+	for y, row := range canvas.Lines {
+		var line strings.Builder
+		for x, r := range row {
+			if canvas.Owner[y][x] == b.focus {
+				line.WriteString(focusedPaneStyle.Render(string(r)))
+			} else {
+				line.WriteString(selectedPreviewStyle.Render(string(r)))
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	preview := lipgloss.NewStyle().Padding(0, 2).Render(strings.Join(lines, "\n"))
+
+	info := fmt.Sprintf("%d panes", b.tree.PaneCount())
+	if p := b.tree.Parent(b.focus); p != nil {
+		share := p.Ratio
+		if p.Second == b.focus {
+			share = 1 - share
+		}
+		info += fmt.Sprintf(" · focused pane %.0f%% of its split", share*100)
+	}
+	status := detailDimStyle.Render(info)
+	if b.status != "" {
+		status = warnStyle.Render(b.status)
+	}
+	status = lipgloss.NewStyle().Padding(1, 2, 0).Render(status)
+
+	selectKey, backKey := b.keys.label(ActionSelect), b.keys.label(ActionBack)
+
+	var help string
+	switch b.mode {
+	case builderLabel:
+		help = helpStyle.Render(strings.TrimRight("Pane label: "+filterStyle.Render(b.input+"▏")+"  "+
+			keyItem(selectKey, "set")+keyItem(backKey, "back"), " "))
+	case builderName:
+		help = helpStyle.Render(strings.TrimRight("Layout name: "+filterStyle.Render(b.input+"▏")+"  "+
+			keyItem(selectKey, "save")+keyItem(backKey, "back"), " "))
+	case builderOverwrite:
+		help = warnStyle.Render(fmt.Sprintf("'%s' already exists — replace it? ", b.pending.ID)) +
+			helpKeyStyle.Render("y") + helpStyle.Render("/") + helpKeyStyle.Render("n")
+	default:
+		help = helpStyle.Render(
+			helpKeyStyle.Render("r") + " split right  " +
+				helpKeyStyle.Render("d") + " split down  " +
+				helpKeyStyle.Render("←→↑↓") + " focus  " +
+				helpKeyStyle.Render("+/-/0") + " resize  " +
+				helpKeyStyle.Render("n") + " label  " +
+				helpKeyStyle.Render("x") + " close  " +
+				strings.TrimRight(helpKeyStyle.Render("s")+" save  "+
+					keyItem(b.keys.label(ActionQuit), "cancel"), " "),
+		)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, preview, status, help)
+}
+
+// Saved returns the layout that was saved, or nil if the builder was
+// cancelled.
+func (b Builder) Saved() *layout.Layout {
+	return b.saved
+}
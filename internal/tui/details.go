@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/atkntepe/tyle/internal/engine"
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+const (
+	panelWidth       = 38
+	bottomPanelLines = 4
+)
+
+func (m Model) sidePanel() bool {
+	return m.width >= 2*m.cardOuterWidth()+panelWidth
+}
+
+func (m Model) current() *layout.Layout {
+	if len(m.matches) == 0 {
+		return nil
+	}
+	return &m.layouts[m.matches[m.cursor].index]
+}
+
+func (m Model) source(id string) string {
+	if s, ok := m.sources[id]; ok {
+		return s
+	}
+	return "preset"
+}
+
+func (m Model) renderDetails(height int) string {
+	l := m.current()
+	if l == nil {
+		return ""
+	}
+
+	steps := engine.ResolveSteps(*l, m.execute)
+	resolved := *l
+	resolved.Steps = steps
+	missing := engine.ValidateBindings(resolved, m.bindings)
+
+	meta := fmt.Sprintf("%d panes · %s", l.PaneCount, m.source(l.ID))
+
+	if !m.sidePanel() {
+		width := m.width - 4
+		lines := []string{
+			detailTitleStyle.Render(l.Name) + "  " + detailDimStyle.Render(meta),
+			detailTextStyle.Render(truncate(l.Description, width)),
+			detailDimStyle.Render(truncate(joinSteps(steps), width)),
+		}
+		if len(missing) > 0 {
+			lines = append(lines, warnStyle.Render(truncate("missing: "+strings.Join(missing, ", "), width)))
+		}
+		return detailPanelStyle.Width(m.width - 2).Height(bottomPanelLines).Render(strings.Join(lines, "\n"))
+	}
+
+	inner := panelWidth - 4
+	lines := []string{
+		detailTitleStyle.Render(truncate(l.Name, inner)),
+		detailDimStyle.Render(meta),
+		"",
+	}
+	if l.Description != "" {
+		lines = append(lines, detailTextStyle.Width(inner).Render(l.Description), "")
+	}
+	if len(missing) > 0 {
+		lines = append(lines, warnStyle.Render("Missing Ghostty bindings:"))
+		for _, action := range missing {
+			lines = append(lines, warnStyle.Render("  "+truncate(action, inner-2)))
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, detailDimStyle.Render("Steps:"))
+	for i, step := range steps {
+		lines = append(lines, detailTextStyle.Render(fmt.Sprintf("%2d. %s", i+1, step)))
+	}
+
+	content := strings.Join(lines, "\n")
+	if h := height - 2; h > 0 && lipgloss.Height(content) > h {
+		content = strings.Join(strings.Split(content, "\n")[:h], "\n")
+	}
+	return detailPanelStyle.Width(panelWidth - 2).Render(content)
+}
+
+func joinSteps(steps []layout.LayoutStep) string {
+	parts := make([]string, len(steps))
+	for i, s := range steps {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, " → ")
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width < 1 {
+		return ""
+	}
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+type match struct {
+	index      int
+	score      int
+	label      string
+	highlights []int
+}
+
+type query struct {
+	terms []string
+	panes []int
+}
+
+// parseQuery splits the filter text into fuzzy terms and pane-count
+// filters. A bare number or "panes:N" filters by pane count.
+func parseQuery(text string) query {
+	var q query
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		value := strings.TrimPrefix(field, "panes:")
+		if n, err := strconv.Atoi(value); err == nil {
+			q.panes = append(q.panes, n)
+			continue
+		}
+		if value == "" {
+			continue
+		}
+		q.terms = append(q.terms, field)
+	}
+	return q
+}
+
+func filterLayouts(layouts []layout.Layout, text string) []match {
+	q := parseQuery(text)
+
+	var matches []match
+	for i, l := range layouts {
+		m, ok := matchLayout(l, q)
+		if !ok {
+			continue
+		}
+		m.index = i
+		matches = append(matches, m)
+	}
+
+	if len(q.terms) > 0 {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+	return matches
+}
+
+func matchLayout(l layout.Layout, q query) (match, bool) {
+	for _, n := range q.panes {
+		if l.PaneCount != n {
+			return match{}, false
+		}
+	}
+
+	m := match{label: l.Name}
+	if len(q.terms) == 0 {
+		return m, true
+	}
+
+	fields := []string{l.Name, l.ID, l.Description}
+	best := -1
+	for f, text := range fields {
+		score := 0
+		var highlights []int
+		ok := true
+		for _, term := range q.terms {
+			s, pos, found := fuzzyMatch(term, text)
+			if !found {
+				ok = false
+				break
+			}
+			score += s
+			highlights = append(highlights, pos...)
+		}
+		if !ok {
+			continue
+		}
+		// Prefer name matches over ID and description matches.
+		score -= f * 2
+		if best < 0 || score > m.score {
+			best = f
+			m.score = score
+			m.label = text
+			m.highlights = highlights
+		}
+	}
+
+	return m, best >= 0
+}
+
+// fuzzyMatch reports whether pattern is a case-insensitive subsequence of
+// text, returning a score that rewards consecutive runs and matches at word
+// starts, and the rune positions that matched.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	var positions []int
+	score := 0
+	pi := 0
+	prev := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 2
+		}
+		positions = append(positions, ti)
+		prev = ti
+		pi++
+	}
+
+	if pi < len(p) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
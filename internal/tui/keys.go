@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Action is something the picker does in response to a key.
+type Action string
+
+const (
+	ActionLeft       Action = "left"
+	ActionRight      Action = "right"
+	ActionUp         Action = "up"
+	ActionDown       Action = "down"
+	ActionSelect     Action = "select"
+	ActionBack       Action = "back"
+	ActionQuit       Action = "quit"
+	ActionFilter     Action = "filter"
+	ActionHelp       Action = "help"
+	ActionSort       Action = "sort"
+	ActionNew        Action = "new"
+	ActionDuplicate  Action = "duplicate"
+	ActionRename     Action = "rename"
+	ActionDelete     Action = "delete"
+	ActionHide       Action = "hide"
+	ActionShowHidden Action = "show_hidden"
+	ActionMoveLeft   Action = "move_left"
+	ActionMoveRight  Action = "move_right"
+	ActionMoveUp     Action = "move_up"
+	ActionMoveDown   Action = "move_down"
+)
+
+// keyActions lists every action in the order the help overlay shows them.
+// manage actions need a Store.
+var keyActions = []struct {
+	action Action
+	desc   string
+	manage bool
+}{
+	{ActionLeft, "move left", false},
+	{ActionRight, "move right", false},
+	{ActionUp, "move up", false},
+	{ActionDown, "move down", false},
+	{ActionSelect, "apply the highlighted layout", false},
+	{ActionFilter, "filter layouts", false},
+	{ActionBack, "clear the filter, or quit", false},
+	{ActionQuit, "quit", false},
+	{ActionSort, "sort by default, recent or frequent", false},
+	{ActionHelp, "show all keys", false},
+	{ActionNew, "build a new layout", true},
+	{ActionDuplicate, "duplicate", true},
+	{ActionRename, "rename", true},
+	{ActionDelete, "delete", true},
+	{ActionHide, "hide or unhide", true},
+	{ActionShowHidden, "show hidden layouts", true},
+	{ActionMoveLeft, "reorder: move left", true},
+	{ActionMoveRight, "reorder: move right", true},
+	{ActionMoveUp, "reorder: move up", true},
+	{ActionMoveDown, "reorder: move down", true},
+}
+
+// Keymap binds picker actions to keys, named the way Bubble Tea reports
+// them ("enter", "shift+left", "ctrl+n", "x"). ctrl+c always quits and
+// 1–9 always apply the visible cards, whatever the keymap says.
+//
+// While typing a filter or a name, letters are text: only bindings on other
+// keys apply there (navigation, select and back), and backspace and ctrl+u
+// edit. The delete prompt answers to y and n. The builder uses select,
+// back and quit from the keymap, and has its own fixed keys for the rest,
+// listed at the bottom of its screen.
+type Keymap map[Action][]string
+
+func DefaultKeymap() Keymap {
+	return Keymap{
+		ActionLeft:       {"left", "h"},
+		ActionRight:      {"right", "l"},
+		ActionUp:         {"up", "k"},
+		ActionDown:       {"down", "j"},
+		ActionSelect:     {"enter"},
+		ActionBack:       {"esc"},
+		ActionQuit:       {"q"},
+		ActionFilter:     {"/"},
+		ActionHelp:       {"?"},
+		ActionSort:       {"s"},
+		ActionNew:        {"n"},
+		ActionDuplicate:  {"c"},
+		ActionRename:     {"r"},
+		ActionDelete:     {"d"},
+		ActionHide:       {"x"},
+		ActionShowHidden: {"."},
+		ActionMoveLeft:   {"shift+left"},
+		ActionMoveRight:  {"shift+right"},
+		ActionMoveUp:     {"shift+up"},
+		ActionMoveDown:   {"shift+down"},
+	}
+}
+
+// ParseKeymap builds a keymap from the defaults, replacing the keys of
+// each action named in overrides. It rejects unknown actions and keys
+// bound to more than one action.
+func ParseKeymap(overrides map[string][]string) (Keymap, error) {
+	keys := DefaultKeymap()
+	for name, bound := range overrides {
+		action := Action(name)
+		if _, ok := keys[action]; !ok {
+			return nil, fmt.Errorf("unknown picker action '%s'", name)
+		}
+		keys[action] = bound
+	}
+
+	owner := map[string]Action{}
+	for _, ka := range keyActions {
+		for _, key := range keys[ka.action] {
+			if key == "ctrl+c" || (len(key) == 1 && key >= "1" && key <= "9") {
+				return nil, fmt.Errorf("key '%s' is reserved and can't be bound to %s", key, ka.action)
+			}
+			if other, ok := owner[key]; ok {
+				return nil, fmt.Errorf("key '%s' is bound to both %s and %s", key, other, ka.action)
+			}
+			owner[key] = ka.action
+		}
+	}
+	return keys, nil
+}
+
+// isTextKey reports whether a key types text, which it does instead of
+// triggering a binding while a filter or name is being typed.
+func isTextKey(msg tea.KeyMsg) bool {
+	return msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace
+}
+
+func (k Keymap) lookup(key string) (Action, bool) {
+	for action, keys := range k {
+		for _, bound := range keys {
+			if bound == key {
+				return action, true
+			}
+		}
+	}
+	return "", false
+}
+
+var keyGlyphs = map[string]string{
+	"left":  "←",
+	"right": "→",
+	"up":    "↑",
+	"down":  "↓",
+	"shift": "⇧",
+}
+
+// keyLabel renders a key name for help text, drawing arrows as glyphs.
+func keyLabel(key string) string {
+	parts := strings.Split(key, "+")
+	for i, p := range parts {
+		if g, ok := keyGlyphs[p]; ok {
+			parts[i] = g
+		}
+	}
+	label := strings.Join(parts, "+")
+	return strings.ReplaceAll(label, "⇧+", "⇧")
+}
+
+// label is the first key bound to an action, as shown in the help line.
+func (k Keymap) label(action Action) string {
+	if len(k[action]) == 0 {
+		return ""
+	}
+	return keyLabel(k[action][0])
+}
+
+// group joins the first keys of several actions, e.g. "←→↑↓" for the four
+// directions. Multi-character keys are separated with slashes.
+func (k Keymap) group(actions ...Action) string {
+	var labels []string
+	glyphs := true
+	for _, a := range actions {
+		l := k.label(a)
+		if l == "" {
+			continue
+		}
+		labels = append(labels, l)
+		if len([]rune(strings.TrimPrefix(l, "⇧"))) > 1 {
+			glyphs = false
+		}
+	}
+	if glyphs {
+		return strings.Join(labels, "")
+	}
+	return strings.Join(labels, "/")
+}
+
+// keyItem renders one entry of a help line, or nothing for an action with
+// no keys bound.
+func keyItem(keys, desc string) string {
+	if keys == "" {
+		return ""
+	}
+	return helpKeyStyle.Render(keys) + " " + desc + "  "
+}
+
+// helpLine generates the short help shown under the grid from the keymap.
+func (m Model) helpLine() string {
+	k := m.keys
+	line := keyItem(k.group(ActionLeft, ActionRight, ActionUp, ActionDown), "navigate") +
+		keyItem(k.label(ActionFilter), "filter") +
+		keyItem(k.label(ActionSelect), "select") +
+		keyItem(k.label(ActionSort), "sort") +
+		keyItem(k.label(ActionBack), "cancel") +
+		keyItem(k.label(ActionHelp), "keys")
+	if m.store != nil {
+		line += "\n" +
+			keyItem(k.label(ActionNew), "new") +
+			keyItem(k.label(ActionDuplicate), "copy") +
+			keyItem(k.label(ActionRename), "rename") +
+			keyItem(k.label(ActionDelete), "delete") +
+			keyItem(k.label(ActionHide), "hide") +
+			keyItem(k.label(ActionShowHidden), "show hidden") +
+			keyItem(k.group(ActionMoveLeft, ActionMoveRight, ActionMoveUp, ActionMoveDown), "move")
+	}
+	return strings.TrimRight(line, " ")
+}
+
+// keysView is the overlay listing every binding in the active keymap.
+func (m Model) keysView() string {
+	header := headerStyle.Render("⊞ tyle  " + filterStyle.Render("keys"))
+
+	type row struct{ keys, desc string }
+	var rows []row
+	for _, ka := range keyActions {
+		if ka.manage && m.store == nil {
+			continue
+		}
+		labels := make([]string, len(m.keys[ka.action]))
+		for i, key := range m.keys[ka.action] {
+			labels[i] = keyLabel(key)
+		}
+		rows = append(rows, row{strings.Join(labels, ", "), ka.desc})
+	}
+	rows = append(rows,
+		row{"1-9", "apply the Nth layout on screen"},
+		row{"ctrl+c", "quit"},
+		row{"", ""},
+		row{"letters", "type while filtering or naming; other bindings still apply"},
+		row{"y, n", "answer the delete prompt"},
+	)
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, lipgloss.Width(r.keys))
+	}
+
+	lines := make([]string, len(rows))
+	for i, r := range rows {
+		pad := strings.Repeat(" ", width-lipgloss.Width(r.keys))
+		lines[i] = helpKeyStyle.Render(r.keys) + pad + "  " + detailTextStyle.Render(r.desc)
+	}
+
+	body := lipgloss.NewStyle().Padding(0, 2).Render(strings.Join(lines, "\n"))
+	help := helpStyle.Render("press any key to close")
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, help)
+}
@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/atkntepe/tyle/internal/layout"
+)
+
+// Store persists changes made from the picker.
+type Store interface {
+	SaveLayout(l layout.Layout, replace bool) error
+	DeleteLayout(id string) error
+	RenameLayout(id, name string) (layout.Layout, error)
+	SetHidden(id string, hidden bool) error
+	SetOrder(ids []string) error
+}
+
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	promptDelete
+	promptRename
+	promptDuplicate
+)
+
+func (m Model) startPrompt(kind promptKind) (Model, tea.Cmd) {
+	l := m.current()
+	if l == nil || m.store == nil {
+		return m, nil
+	}
+
+	switch kind {
+	case promptDelete, promptRename:
+		if m.sources[l.ID] != "custom" {
+			m.status = fmt.Sprintf("%s is a %s layout — duplicate it to get an editable copy", l.Name, m.source(l.ID))
+			return m, nil
+		}
+	}
+
+	m.prompt = kind
+	m.input = ""
+	switch kind {
+	case promptRename:
+		m.input = l.Name
+	case promptDuplicate:
+		m.input = l.Name + " Copy"
+	}
+	return m, nil
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.prompt == promptDelete {
+		action, _ := m.keys.lookup(msg.String())
+		switch {
+		case msg.String() == "y" || msg.String() == "Y":
+			m.prompt = promptNone
+			m.deleteCurrent()
+		case msg.String() == "n" || msg.String() == "N" || action == ActionBack || action == ActionQuit:
+			m.prompt = promptNone
+		case msg.Type == tea.KeyCtrlC:
+			m.cancelled = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	action, bound := m.keys.lookup(msg.String())
+	if isTextKey(msg) {
+		bound = false
+	}
+	switch {
+
+	case msg.Type == tea.KeyCtrlC:
+		m.cancelled = true
+		return m, tea.Quit
+
+	case bound && action == ActionBack:
+		m.prompt = promptNone
+
+	case bound && action == ActionSelect:
+		name := strings.TrimSpace(m.input)
+		kind := m.prompt
+		m.prompt = promptNone
+		if kind == promptRename {
+			m.renameCurrent(name)
+		} else {
+			m.duplicateCurrent(name)
+		}
+
+	case msg.Type == tea.KeyBackspace:
+		if m.input != "" {
+			runes := []rune(m.input)
+			m.input = string(runes[:len(runes)-1])
+		}
+
+	case msg.Type == tea.KeyCtrlU:
+		m.input = ""
+
+	case isTextKey(msg):
+		m.input += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+func (m Model) promptView() string {
+	l := m.current()
+	if l == nil {
+		return ""
+	}
+	switch m.prompt {
+	case promptDelete:
+		return helpStyle.Render(warnStyle.Render(fmt.Sprintf("Delete %s?", l.Name)) + "  " +
+			helpKeyStyle.Render("y") + " delete  " + helpKeyStyle.Render("n") + " keep")
+	case promptRename:
+		return helpStyle.Render(strings.TrimRight("Rename to: "+filterStyle.Render(m.input+"▏")+"  "+
+			keyItem(m.keys.label(ActionSelect), "rename")+keyItem(m.keys.label(ActionBack), "cancel"), " "))
+	case promptDuplicate:
+		return helpStyle.Render(strings.TrimRight("Copy name: "+filterStyle.Render(m.input+"▏")+"  "+
+			keyItem(m.keys.label(ActionSelect), "duplicate")+keyItem(m.keys.label(ActionBack), "cancel"), " "))
+	}
+	return ""
+}
+
+func (m *Model) toggleHidden() {
+	l := m.current()
+	if l == nil || m.store == nil {
+		return
+	}
+
+	hidden := !m.hidden[l.ID]
+	if err := m.store.SetHidden(l.ID, hidden); err != nil {
+		m.status = fmt.Sprintf("Failed to save: %v", err)
+		return
+	}
+	m.hidden[l.ID] = hidden
+
+	if hidden {
+		m.status = fmt.Sprintf("Hid %s", l.Name)
+	} else {
+		m.status = fmt.Sprintf("Showing %s", l.Name)
+	}
+	m.refilter(l.ID)
+}
+
+func (m *Model) deleteCurrent() {
+	l := m.current()
+	if l == nil {
+		return
+	}
+	if err := m.store.DeleteLayout(l.ID); err != nil {
+		m.status = fmt.Sprintf("Failed to delete: %v", err)
+		return
+	}
+
+	idx := m.matches[m.cursor].index
+	m.layouts = append(m.layouts[:idx:idx], m.layouts[idx+1:]...)
+	delete(m.sources, l.ID)
+	delete(m.hidden, l.ID)
+	m.status = fmt.Sprintf("Deleted %s", l.Name)
+
+	cursor := m.cursor
+	m.refilter("")
+	m.cursor = min(cursor, max(len(m.matches)-1, 0))
+}
+
+func (m *Model) renameCurrent(name string) {
+	l := m.current()
+	if l == nil || name == "" || name == l.Name {
+		return
+	}
+
+	renamed, err := m.store.RenameLayout(l.ID, name)
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to rename: %v", err)
+		return
+	}
+
+	idx := m.matches[m.cursor].index
+	oldID := l.ID
+	m.layouts[idx] = renamed
+	if oldID != renamed.ID {
+		m.sources[renamed.ID] = m.sources[oldID]
+		delete(m.sources, oldID)
+		m.hidden[renamed.ID] = m.hidden[oldID]
+		delete(m.hidden, oldID)
+	}
+	m.status = fmt.Sprintf("Renamed to %s", renamed.Name)
+	m.refilter(renamed.ID)
+}
+
+func (m *Model) duplicateCurrent(name string) {
+	l := m.current()
+	if l == nil || name == "" {
+		return
+	}
+
+	dup := *l
+	dup.ID = layout.Slugify(name)
+	dup.Name = name
+	if dup.ID == "" {
+		m.status = "Name must contain letters or digits"
+		return
+	}
+	for _, existing := range m.layouts {
+		if existing.ID == dup.ID {
+			m.status = fmt.Sprintf("A layout with ID '%s' already exists", dup.ID)
+			return
+		}
+	}
+
+	if err := m.store.SaveLayout(dup, false); err != nil {
+		m.status = fmt.Sprintf("Failed to save: %v", err)
+		return
+	}
+
+	idx := m.matches[m.cursor].index
+	m.layouts = append(m.layouts[:idx+1:idx+1], append([]layout.Layout{dup}, m.layouts[idx+1:]...)...)
+	m.sources[dup.ID] = "custom"
+	m.status = fmt.Sprintf("Created %s", dup.Name)
+	m.refilter(dup.ID)
+}
+
+// move swaps the current layout with the one delta places away in the
+// grid and saves the new order.
+func (m *Model) move(delta int) {
+	if m.store == nil || len(m.matches) == 0 {
+		return
+	}
+	if m.query != "" {
+		m.status = "Clear the filter to reorder layouts"
+		return
+	}
+	if m.sort != SortDefault {
+		m.status = "Switch back to the default sort to reorder layouts"
+		return
+	}
+
+	target := m.cursor + delta
+	if target < 0 || target >= len(m.matches) {
+		return
+	}
+
+	a, b := m.matches[m.cursor].index, m.matches[target].index
+	id := m.layouts[a].ID
+	m.layouts[a], m.layouts[b] = m.layouts[b], m.layouts[a]
+
+	ids := make([]string, len(m.layouts))
+	for i, l := range m.layouts {
+		ids[i] = l.ID
+	}
+	if err := m.store.SetOrder(ids); err != nil {
+		m.status = fmt.Sprintf("Failed to save: %v", err)
+	}
+	m.refilter(id)
+}
+
+// refilter recomputes the matches, keeping the cursor on the layout with
+// the given ID if it is still shown.
+func (m *Model) refilter(id string) {
+	m.matches = m.filter()
+	m.cursor = min(m.cursor, max(len(m.matches)-1, 0))
+	for i, mt := range m.matches {
+		if m.layouts[mt.index].ID == id {
+			m.cursor = i
+		}
+	}
+}
+
+func (m Model) filter() []match {
+	matches := m.sortMatches(filterLayouts(m.layouts, m.query))
+	if m.showHidden {
+		return matches
+	}
+
+	var visible []match
+	for _, mt := range matches {
+		if !m.hidden[m.layouts[mt.index].ID] {
+			visible = append(visible, mt)
+		}
+	}
+	return visible
+}
@@ -7,26 +7,129 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/atkntepe/tyle/internal/engine"
 	"github.com/atkntepe/tyle/internal/layout"
 )
 
-const maxColumns = 4
+const (
+	defaultMaxColumns = 4
+	headerHeight      = 3
+	helpHeight        = 4
+)
+
+type Options struct {
+	// MaxColumns caps the grid width; zero or less uses the default.
+	MaxColumns int
+	// Sources maps custom layout IDs to where they were defined, e.g.
+	// "custom" or "project". Layouts not listed are shown as presets.
+	Sources  map[string]string
+	Bindings map[string]engine.KeyCombo
+	Execute  engine.Options
+	// Hidden lists layouts hidden from the picker. They can be shown
+	// dimmed and unhidden from inside it.
+	Hidden []string
+	// Store persists layouts created, edited or reordered in the picker.
+	// Those actions are unavailable when it is nil.
+	Store Store
+	// Sort is the initial sort mode. Recent lists layout IDs by when they
+	// were last applied, newest first, and Counts how often; the cursor
+	// starts on the most recent one.
+	Sort   SortMode
+	Recent []string
+	Counts map[string]int
+	// Keys remaps picker actions; nil uses DefaultKeymap.
+	Keys Keymap
+	// Apply runs the selected layout while the picker shows progress. When
+	// nil, or when the layout types text, the picker quits on selection and
+	// leaves applying to the caller (see Applied).
+	Apply ApplyFunc
+}
 
 type Model struct {
-	layouts   []layout.Layout
-	cursor    int
-	selected  *layout.Layout
-	cancelled bool
-	width     int
-	height    int
-	scroll    int
+	layouts    []layout.Layout
+	cursor     int
+	selected   *layout.Layout
+	cancelled  bool
+	width      int
+	height     int
+	scroll     int
+	maxColumns int
+
+	filtering bool
+	query     string
+	matches   []match
+
+	sources  map[string]string
+	bindings map[string]engine.KeyCombo
+	execute  engine.Options
+
+	store      Store
+	builder    *Builder
+	hidden     map[string]bool
+	showHidden bool
+	prompt     promptKind
+	input      string
+	status     string
+
+	keys     Keymap
+	showKeys bool
+
+	sort   SortMode
+	recent map[string]int
+	counts map[string]int
+
+	apply    ApplyFunc
+	applying *applyState
 }
 
-func NewModel(layouts []layout.Layout) Model {
-	return Model{
-		layouts: layouts,
-		cursor:  0,
+func NewModel(layouts []layout.Layout, opts Options) Model {
+	maxColumns := opts.MaxColumns
+	if maxColumns <= 0 {
+		maxColumns = defaultMaxColumns
+	}
+	hidden := map[string]bool{}
+	for _, id := range opts.Hidden {
+		hidden[id] = true
+	}
+	sources := map[string]string{}
+	for id, src := range opts.Sources {
+		sources[id] = src
+	}
+
+	keys := opts.Keys
+	if keys == nil {
+		keys = DefaultKeymap()
 	}
+
+	recent := map[string]int{}
+	for i, id := range opts.Recent {
+		recent[id] = i
+	}
+	sortMode := opts.Sort
+	if sortMode == "" {
+		sortMode = SortDefault
+	}
+
+	m := Model{
+		keys:       keys,
+		sort:       sortMode,
+		recent:     recent,
+		counts:     opts.Counts,
+		layouts:    layouts,
+		cursor:     0,
+		maxColumns: maxColumns,
+		sources:    sources,
+		bindings:   opts.Bindings,
+		execute:    opts.Execute,
+		store:      opts.Store,
+		hidden:     hidden,
+		apply:      opts.Apply,
+	}
+	m.matches = m.filter()
+	if len(opts.Recent) > 0 {
+		m.refilter(opts.Recent[0])
+	}
+	return m
 }
 
 func (m Model) cardOuterWidth() int {
@@ -49,23 +152,26 @@ func (m Model) cardOuterHeight() int {
 			maxH = len(l.Preview)
 		}
 	}
+	if m.query != "" {
+		maxH++
+	}
 	return maxH + 2
 }
 
 func (m Model) cols() int {
 	if m.width <= 0 {
-		return 3
+		return min(3, m.maxColumns)
 	}
 	ow := m.cardOuterWidth()
 	if ow <= 0 {
-		return 3
+		return min(3, m.maxColumns)
 	}
-	c := m.width / ow
+	c := m.gridWidth() / ow
 	if c < 1 {
 		return 1
 	}
-	if c > maxColumns {
-		return maxColumns
+	if c > m.maxColumns {
+		return m.maxColumns
 	}
 	return c
 }
@@ -75,6 +181,13 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.applying != nil {
+		return m.updateApply(msg)
+	}
+	if m.builder != nil {
+		return m.updateBuilder(msg)
+	}
+
 	switch msg := msg.(type) {
 
 	case tea.WindowSizeMsg:
@@ -84,37 +197,101 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		cols := m.cols()
+		m.status = ""
+		if m.prompt != promptNone {
+			return m.updatePrompt(msg)
+		}
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
 
-		switch msg.String() {
+		if m.showKeys {
+			m.showKeys = false
+			if msg.Type == tea.KeyCtrlC {
+				m.cancelled = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
 
-		case "ctrl+c", "q", "esc":
+		key := msg.String()
+		if key == "ctrl+c" {
 			m.cancelled = true
 			return m, tea.Quit
+		}
+		if len(key) == 1 && key >= "1" && key <= "9" {
+			return m.quickSelect(int(key[0] - '1'))
+		}
+
+		action, _ := m.keys.lookup(key)
+		switch action {
 
-		case "enter":
-			m.selected = &m.layouts[m.cursor]
+		case ActionQuit:
+			m.cancelled = true
 			return m, tea.Quit
 
-		case "left", "h":
-			if m.cursor > 0 {
-				m.cursor--
+		case ActionBack:
+			if m.query != "" {
+				m.setQuery("")
+				break
 			}
+			m.cancelled = true
+			return m, tea.Quit
+
+		case ActionFilter:
+			m.filtering = true
+
+		case ActionHelp:
+			m.showKeys = true
 
-		case "right", "l":
-			if m.cursor < len(m.layouts)-1 {
-				m.cursor++
+		case ActionSort:
+			m.sort = m.sort.next()
+			id := ""
+			if l := m.current(); l != nil {
+				id = l.ID
 			}
+			m.refilter(id)
 
-		case "up", "k":
-			if m.cursor-cols >= 0 {
-				m.cursor -= cols
+		case ActionNew:
+			if m.store != nil {
+				b := newEmbeddedBuilder(m.store.SaveLayout, m.keys, m.width, m.height)
+				m.builder = &b
 			}
 
-		case "down", "j":
-			if m.cursor+cols < len(m.layouts) {
-				m.cursor += cols
+		case ActionHide:
+			m.toggleHidden()
+
+		case ActionShowHidden:
+			m.showHidden = !m.showHidden
+			id := ""
+			if l := m.current(); l != nil {
+				id = l.ID
 			}
+			m.refilter(id)
+
+		case ActionDelete:
+			return m.startPrompt(promptDelete)
+
+		case ActionRename:
+			return m.startPrompt(promptRename)
+
+		case ActionDuplicate:
+			return m.startPrompt(promptDuplicate)
+
+		case ActionMoveLeft:
+			m.move(-1)
+		case ActionMoveRight:
+			m.move(1)
+		case ActionMoveUp:
+			m.move(-m.cols())
+		case ActionMoveDown:
+			m.move(m.cols())
+
+		case ActionSelect:
+			return m.selectCurrent()
+
+		case ActionLeft, ActionRight, ActionUp, ActionDown:
+			m.navigate(action)
 		}
 
 		m.scroll = m.ensureVisible(m.cursor)
@@ -123,6 +300,158 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) updateBuilder(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width = size.Width
+		m.height = size.Height
+	}
+
+	b, cmd := m.builder.update(msg)
+	if !b.done {
+		m.builder = &b
+		return m, cmd
+	}
+
+	m.builder = nil
+	if saved := b.Saved(); saved != nil {
+		replaced := false
+		for i := range m.layouts {
+			if m.layouts[i].ID == saved.ID {
+				m.layouts[i] = *saved
+				replaced = true
+			}
+		}
+		if !replaced {
+			m.layouts = append(m.layouts, *saved)
+		}
+		if m.sources == nil {
+			m.sources = map[string]string{}
+		}
+		m.sources[saved.ID] = "custom"
+		m.setQuery("")
+		for i, mt := range m.matches {
+			if m.layouts[mt.index].ID == saved.ID {
+				m.cursor = i
+			}
+		}
+	}
+	m.scroll = m.ensureVisible(m.cursor)
+	return m, cmd
+}
+
+// updateFilter handles keys while typing a filter. Letters always go into
+// the query, so only keymap bindings on other keys (arrows, enter, esc,
+// ctrl+…) navigate, select or leave the filter.
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	action, bound := m.keys.lookup(msg.String())
+	if isTextKey(msg) {
+		bound = false
+	}
+	switch {
+
+	case msg.Type == tea.KeyCtrlC:
+		m.cancelled = true
+		return m, tea.Quit
+
+	case bound && action == ActionBack:
+		m.filtering = false
+		m.setQuery("")
+
+	case bound && action == ActionSelect:
+		return m.selectCurrent()
+
+	case bound && (action == ActionLeft || action == ActionRight || action == ActionUp || action == ActionDown):
+		m.navigate(action)
+
+	case msg.Type == tea.KeyBackspace:
+		if m.query != "" {
+			runes := []rune(m.query)
+			m.setQuery(string(runes[:len(runes)-1]))
+		}
+
+	case msg.Type == tea.KeyCtrlU:
+		m.setQuery("")
+
+	case isTextKey(msg):
+		m.setQuery(m.query + string(msg.Runes))
+	}
+
+	m.scroll = m.ensureVisible(m.cursor)
+	return m, nil
+}
+
+func (m *Model) setQuery(q string) {
+	m.query = q
+	m.matches = m.filter()
+	m.cursor = 0
+	m.scroll = 0
+}
+
+func (m Model) selectCurrent() (tea.Model, tea.Cmd) {
+	if len(m.matches) == 0 {
+		return m, nil
+	}
+	m.selected = &m.layouts[m.matches[m.cursor].index]
+	if m.apply != nil && !typesText(*m.selected, m.execute) {
+		return m.startApply(*m.selected)
+	}
+	return m, tea.Quit
+}
+
+// quickSelect picks the nth card currently on screen.
+func (m Model) quickSelect(n int) (tea.Model, tea.Cmd) {
+	i := m.scroll*m.cols() + n
+	if i >= len(m.matches) {
+		return m, nil
+	}
+	m.cursor = i
+	return m.selectCurrent()
+}
+
+func (m *Model) navigate(action Action) {
+	cols := m.cols()
+
+	switch action {
+	case ActionLeft:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case ActionRight:
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+
+	case ActionUp:
+		if m.cursor-cols >= 0 {
+			m.cursor -= cols
+		}
+
+	case ActionDown:
+		if m.cursor+cols < len(m.matches) {
+			m.cursor += cols
+		}
+	}
+}
+
+func (m Model) gridWidth() int {
+	if m.sidePanel() {
+		return m.width - panelWidth
+	}
+	return m.width
+}
+
+func (m Model) gridHeight() int {
+	available := m.height - headerHeight - helpHeight
+	if !m.sidePanel() {
+		available -= bottomPanelLines + 2
+	}
+	if available < 1 {
+		available = 1
+	}
+	return available
+}
+
 func (m Model) ensureVisible(cursor int) int {
 	if m.height <= 0 {
 		return 0
@@ -131,10 +460,8 @@ func (m Model) ensureVisible(cursor int) int {
 	cols := m.cols()
 	row := cursor / cols
 
-	headerHeight := 3
-	helpHeight := 3
 	ch := m.cardOuterHeight()
-	available := m.height - headerHeight - helpHeight
+	available := m.gridHeight()
 	visibleRows := available / ch
 	if visibleRows < 1 {
 		visibleRows = 1
@@ -151,19 +478,37 @@ func (m Model) ensureVisible(cursor int) int {
 }
 
 func (m Model) View() string {
-	header := headerStyle.Render("⊞ tyle")
+	if m.applying != nil {
+		return m.applyView()
+	}
+	if m.builder != nil {
+		return m.builder.View()
+	}
+	if m.showKeys {
+		return m.keysView()
+	}
+
+	title := "⊞ tyle"
+	if m.filtering || m.query != "" {
+		prompt := "/" + m.query
+		if m.filtering {
+			prompt += "▏"
+		}
+		title += "  " + filterStyle.Render(prompt)
+	} else if m.sort != SortDefault {
+		title += "  " + detailDimStyle.Render("sorted by "+string(m.sort))
+	}
+	header := headerStyle.Render(title)
 
 	cols := m.cols()
-	grid := renderGrid(m.layouts, m.cursor, cols)
+	dim := measureLayouts(m.layouts)
+	grid := renderGrid(m.layouts, m.matches, m.cursor, cols, dim, m.query != "", m.hidden)
+	if len(m.matches) == 0 {
+		grid = emptyStyle.Render("No layouts match")
+	}
 
 	gridLines := strings.Split(grid, "\n")
-
-	headerHeight := 3
-	helpHeight := 3
-	available := m.height - headerHeight - helpHeight
-	if available < 1 {
-		available = 1
-	}
+	available := m.gridHeight()
 
 	ch := m.cardOuterHeight()
 	startLine := m.scroll * ch
@@ -177,13 +522,35 @@ func (m Model) View() string {
 
 	visibleGrid := strings.Join(gridLines[startLine:endLine], "\n")
 
-	help := helpStyle.Render(
-		helpKeyStyle.Render("←→↑↓") + " navigate  " +
-			helpKeyStyle.Render("enter") + " select  " +
-			helpKeyStyle.Render("esc") + " cancel",
-	)
+	help := helpStyle.Render(m.helpLine())
+	if m.filtering {
+		help = helpStyle.Render(
+			"type to filter, " + helpKeyStyle.Render("panes:N") + " or a number for pane count  " +
+				strings.TrimRight(
+					keyItem(m.keys.label(ActionSelect), "select")+
+						keyItem(m.keys.label(ActionBack), "clear"), " "),
+		)
+	}
+	if m.prompt != promptNone {
+		help = m.promptView()
+	} else if m.status != "" {
+		help = helpStyle.Render(warnStyle.Render(m.status))
+	}
+
+	var body string
+	if m.sidePanel() {
+		body = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(m.gridWidth()).Render(visibleGrid),
+			m.renderDetails(m.height-headerHeight-helpHeight))
+	} else {
+		body = lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Height(available).Render(visibleGrid),
+			m.renderDetails(bottomPanelLines+2))
+	}
+
+	help = lipgloss.PlaceVertical(helpHeight, lipgloss.Top, help)
 
-	return lipgloss.JoinVertical(lipgloss.Left, header, visibleGrid, help)
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, help)
 }
 
 func (m Model) Selected() *layout.Layout {
@@ -30,19 +30,19 @@ func measureLayouts(layouts []layout.Layout) cardDimensions {
 	return cardDimensions{width: maxW + 2, height: maxH}
 }
 
-func renderGrid(layouts []layout.Layout, cursor int, cols int) string {
-	dim := measureLayouts(layouts)
+func renderGrid(layouts []layout.Layout, matches []match, cursor int, cols int, dim cardDimensions, showLabels bool, hidden map[string]bool) string {
 	var rows []string
 
-	for i := 0; i < len(layouts); i += cols {
+	for i := 0; i < len(matches); i += cols {
 		end := i + cols
-		if end > len(layouts) {
-			end = len(layouts)
+		if end > len(matches) {
+			end = len(matches)
 		}
 
 		var cards []string
 		for j := i; j < end; j++ {
-			cards = append(cards, renderCard(layouts[j], j == cursor, dim))
+			l := layouts[matches[j].index]
+			cards = append(cards, renderCard(l, matches[j], j == cursor, hidden[l.ID], dim, showLabels))
 		}
 
 		row := lipgloss.JoinHorizontal(lipgloss.Top, cards...)
@@ -52,16 +52,53 @@ func renderGrid(layouts []layout.Layout, cursor int, cols int) string {
 	return lipgloss.JoinVertical(lipgloss.Left, rows...)
 }
 
-func renderCard(l layout.Layout, isSelected bool, dim cardDimensions) string {
+func renderCard(l layout.Layout, mt match, isSelected, isHidden bool, dim cardDimensions, showLabel bool) string {
 	base := cardBase
 	pvStyle := previewStyle
 
+	if isHidden {
+		base = hiddenCardBase
+		pvStyle = hiddenPreviewStyle
+	}
 	if isSelected {
 		base = selectedCardBase
 		pvStyle = selectedPreviewStyle
 	}
 
-	style := base.Width(dim.width).Height(dim.height)
-	preview := pvStyle.Render(strings.Join(l.Preview, "\n"))
-	return style.Render(preview)
+	content := pvStyle.Render(strings.Join(l.Preview, "\n"))
+	height := dim.height
+	if showLabel {
+		content = lipgloss.JoinVertical(lipgloss.Left, content, renderLabel(mt, dim.width-2, pvStyle))
+		height++
+	}
+
+	style := base.Width(dim.width).Height(height)
+	return style.Render(content)
+}
+
+// renderLabel truncates the matched text to width runes and highlights the
+// runes that matched the filter.
+func renderLabel(mt match, width int, style lipgloss.Style) string {
+	if width < 1 {
+		return ""
+	}
+	runes := []rune(mt.label)
+	if len(runes) > width {
+		runes = append(runes[:width-1], '…')
+	}
+
+	hl := make(map[int]bool, len(mt.highlights))
+	for _, p := range mt.highlights {
+		hl[p] = true
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if hl[i] && !(i == len(runes)-1 && r == '…') {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteString(style.Render(string(r)))
+		}
+	}
+	return b.String()
 }
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortMode is the order the picker lists layouts in when not filtering.
+type SortMode string
+
+const (
+	SortDefault  SortMode = "default"
+	SortRecent   SortMode = "recent"
+	SortFrequent SortMode = "frequent"
+)
+
+var sortModes = []SortMode{SortDefault, SortRecent, SortFrequent}
+
+func ParseSortMode(s string) (SortMode, error) {
+	if s == "" {
+		return SortDefault, nil
+	}
+	for _, mode := range sortModes {
+		if SortMode(s) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sort '%s' — use default, recent or frequent", s)
+}
+
+func (s SortMode) next() SortMode {
+	for i, mode := range sortModes {
+		if mode == s {
+			return sortModes[(i+1)%len(sortModes)]
+		}
+	}
+	return SortDefault
+}
+
+// sortMatches orders matches by the sort mode. Filtered results keep their
+// score order, and layouts without history keep their configured order
+// after the ones with it.
+func (m Model) sortMatches(matches []match) []match {
+	if m.query != "" || m.sort == SortDefault {
+		return matches
+	}
+
+	rank := func(mt match) int {
+		id := m.layouts[mt.index].ID
+		switch m.sort {
+		case SortRecent:
+			if r, ok := m.recent[id]; ok {
+				return r
+			}
+			return len(m.recent)
+		case SortFrequent:
+			return -m.counts[id]
+		}
+		return 0
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return rank(matches[i]) < rank(matches[j])
+	})
+	return matches
+}
@@ -1,38 +1,257 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/atkntepe/tyle/internal/engine"
+)
+
+// Theme holds the colours and borders the picker and builder draw with.
+type Theme struct {
+	Card     lipgloss.TerminalColor // card and panel borders
+	Selected lipgloss.TerminalColor // highlighted card, matches and titles
+	Preview  lipgloss.TerminalColor
+	Header   lipgloss.TerminalColor
+	Help     lipgloss.TerminalColor
+	Text     lipgloss.TerminalColor
+	Faint    lipgloss.TerminalColor // hidden layouts
+	Warn     lipgloss.TerminalColor
+	Error    lipgloss.TerminalColor
+	Focus    lipgloss.TerminalColor // background of the focused builder pane
+
+	Border         lipgloss.Border
+	SelectedBorder lipgloss.Border
+}
+
+func DarkTheme() Theme {
+	return Theme{
+		Card:           lipgloss.Color("241"),
+		Selected:       lipgloss.Color("86"),
+		Preview:        lipgloss.Color("241"),
+		Header:         lipgloss.Color("86"),
+		Help:           lipgloss.Color("241"),
+		Text:           lipgloss.Color("255"),
+		Faint:          lipgloss.Color("238"),
+		Warn:           lipgloss.Color("214"),
+		Error:          lipgloss.Color("203"),
+		Focus:          lipgloss.Color("30"),
+		Border:         lipgloss.RoundedBorder(),
+		SelectedBorder: lipgloss.RoundedBorder(),
+	}
+}
+
+func LightTheme() Theme {
+	return Theme{
+		Card:           lipgloss.Color("245"),
+		Selected:       lipgloss.Color("30"),
+		Preview:        lipgloss.Color("243"),
+		Header:         lipgloss.Color("30"),
+		Help:           lipgloss.Color("244"),
+		Text:           lipgloss.Color("235"),
+		Faint:          lipgloss.Color("252"),
+		Warn:           lipgloss.Color("166"),
+		Error:          lipgloss.Color("160"),
+		Focus:          lipgloss.Color("153"),
+		Border:         lipgloss.RoundedBorder(),
+		SelectedBorder: lipgloss.RoundedBorder(),
+	}
+}
+
+// NoColorTheme draws without colours, marking the selected card with a
+// heavier border instead. It is used when NO_COLOR is set.
+func NoColorTheme() Theme {
+	none := lipgloss.NoColor{}
+	return Theme{
+		Card:           none,
+		Selected:       none,
+		Preview:        none,
+		Header:         none,
+		Help:           none,
+		Text:           none,
+		Faint:          none,
+		Warn:           none,
+		Error:          none,
+		Focus:          none,
+		Border:         lipgloss.RoundedBorder(),
+		SelectedBorder: lipgloss.ThickBorder(),
+	}
+}
+
+// GhosttyTheme builds a theme from a Ghostty config's colours so the picker
+// matches the terminal. Palette entries the config doesn't set fall back to
+// the terminal's own ANSI colour of the same index. A light background
+// swaps the text and faint colours so both stay readable.
+func GhosttyTheme(c engine.GhosttyColors) Theme {
+	palette := func(i int) lipgloss.TerminalColor {
+		if c.Palette[i] != "" {
+			return lipgloss.Color(c.Palette[i])
+		}
+		return lipgloss.Color(fmt.Sprint(i))
+	}
+
+	light := isLight(c.Background)
+
+	t := DarkTheme()
+	if light {
+		t = LightTheme()
+	}
+	t.Card = palette(8)
+	t.Selected = palette(6)
+	t.Preview = palette(8)
+	t.Header = palette(6)
+	t.Help = palette(8)
+	t.Text, t.Faint = palette(15), palette(0)
+	if light {
+		t.Text, t.Faint = palette(0), palette(7)
+	}
+	if c.Foreground != "" {
+		t.Text = lipgloss.Color(c.Foreground)
+	}
+	t.Warn = palette(3)
+	t.Error = palette(1)
+	t.Focus = palette(4)
+	return t
+}
+
+// isLight reports whether a #rrggbb colour is closer to white than black.
+// Anything else, including an unset background, counts as dark.
+func isLight(hex string) bool {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return false
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return false
+	}
+	r, g, b := float64(rgb>>16&0xff), float64(rgb>>8&0xff), float64(rgb&0xff)
+	return 0.299*r+0.587*g+0.114*b > 127.5
+}
+
+// BorderByName returns the lipgloss border for a theme border name.
+func BorderByName(name string) (lipgloss.Border, bool) {
+	switch name {
+	case "rounded":
+		return lipgloss.RoundedBorder(), true
+	case "normal":
+		return lipgloss.NormalBorder(), true
+	case "thick":
+		return lipgloss.ThickBorder(), true
+	case "double":
+		return lipgloss.DoubleBorder(), true
+	case "hidden":
+		return lipgloss.HiddenBorder(), true
+	}
+	return lipgloss.Border{}, false
+}
 
 var (
-	cyan    = lipgloss.Color("86")
-	gray    = lipgloss.Color("241")
-	white   = lipgloss.Color("255")
+	cardBase             lipgloss.Style
+	selectedCardBase     lipgloss.Style
+	hiddenCardBase       lipgloss.Style
+	hiddenPreviewStyle   lipgloss.Style
+	previewStyle         lipgloss.Style
+	selectedPreviewStyle lipgloss.Style
+	headerStyle          lipgloss.Style
+	helpStyle            lipgloss.Style
+	helpKeyStyle         lipgloss.Style
+	filterStyle          lipgloss.Style
+	matchStyle           lipgloss.Style
+	detailPanelStyle     lipgloss.Style
+	detailTitleStyle     lipgloss.Style
+	detailTextStyle      lipgloss.Style
+	detailDimStyle       lipgloss.Style
+	warnStyle            lipgloss.Style
+	errorStepStyle       lipgloss.Style
+	focusedPaneStyle     lipgloss.Style
+	emptyStyle           lipgloss.Style
+)
 
+func init() {
+	SetTheme(DarkTheme())
+}
+
+// SetTheme changes the styles used by every picker and builder. Call it
+// before starting the program.
+func SetTheme(t Theme) {
 	cardBase = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(gray).
-			Padding(0, 1)
+		Border(t.Border).
+		BorderForeground(t.Card).
+		Padding(0, 1)
 
 	selectedCardBase = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(cyan).
-				Padding(0, 1)
+		Border(t.SelectedBorder).
+		BorderForeground(t.Selected).
+		Padding(0, 1)
+
+	hiddenCardBase = lipgloss.NewStyle().
+		Border(lipgloss.HiddenBorder()).
+		Padding(0, 1)
+
+	hiddenPreviewStyle = lipgloss.NewStyle().
+		Foreground(t.Faint)
 
 	previewStyle = lipgloss.NewStyle().
-			Foreground(gray)
+		Foreground(t.Preview)
 
 	selectedPreviewStyle = lipgloss.NewStyle().
-				Foreground(cyan)
+		Foreground(t.Selected)
 
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(cyan).
-			Padding(1, 2)
+		Bold(true).
+		Foreground(t.Header).
+		Padding(1, 2)
 
 	helpStyle = lipgloss.NewStyle().
-			Foreground(gray).
-			Padding(1, 2)
+		Foreground(t.Help).
+		Padding(1, 2)
 
 	helpKeyStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Bold(true)
-)
+		Foreground(t.Text).
+		Bold(true)
+
+	filterStyle = lipgloss.NewStyle().
+		Foreground(t.Text)
+
+	matchStyle = lipgloss.NewStyle().
+		Foreground(t.Selected).
+		Bold(true).
+		Underline(true)
+
+	detailPanelStyle = lipgloss.NewStyle().
+		Border(t.Border).
+		BorderForeground(t.Card).
+		Padding(0, 1)
+
+	detailTitleStyle = lipgloss.NewStyle().
+		Foreground(t.Selected).
+		Bold(true)
+
+	detailTextStyle = lipgloss.NewStyle().
+		Foreground(t.Text)
+
+	detailDimStyle = lipgloss.NewStyle().
+		Foreground(t.Help)
+
+	warnStyle = lipgloss.NewStyle().
+		Foreground(t.Warn)
+
+	errorStepStyle = lipgloss.NewStyle().
+		Foreground(t.Error).
+		Bold(true)
+
+	focusedPaneStyle = lipgloss.NewStyle().
+		Foreground(t.Text).
+		Background(t.Focus)
+	if _, ok := t.Focus.(lipgloss.NoColor); ok {
+		focusedPaneStyle = lipgloss.NewStyle().Reverse(true)
+	}
+
+	emptyStyle = lipgloss.NewStyle().
+		Foreground(t.Help).
+		Padding(1, 2)
+}
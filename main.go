@@ -2,23 +2,33 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"github.com/atkntepe/tyle/internal/config"
 	"github.com/atkntepe/tyle/internal/engine"
+	"github.com/atkntepe/tyle/internal/history"
 	"github.com/atkntepe/tyle/internal/layout"
+	"github.com/atkntepe/tyle/internal/output"
 	"github.com/atkntepe/tyle/internal/tui"
 )
 
 var version = "dev"
 
+var profileFlag string
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:          "tyle",
@@ -26,25 +36,63 @@ func main() {
 		Version:      version,
 		RunE:         runTUI,
 		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if profileFlag == "" {
+				return nil
+			}
+			if !loadConfig().HasProfile(profileFlag) {
+				return fmt.Errorf("profile '%s' not found — run 'tyle profile list' to see available profiles", profileFlag)
+			}
+			return nil
+		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use the named config profile")
+
 	rootCmd.AddCommand(applyCmd())
 	rootCmd.AddCommand(listCmd())
 	rootCmd.AddCommand(resetCmd())
+	rootCmd.AddCommand(undoCmd())
+	rootCmd.AddCommand(upCmd())
 	rootCmd.AddCommand(initCmd())
 	rootCmd.AddCommand(addCmd())
 	rootCmd.AddCommand(hideCmd())
 	rootCmd.AddCommand(showCmd())
+	rootCmd.AddCommand(rmCmd())
+	rootCmd.AddCommand(renameCmd())
+	rootCmd.AddCommand(duplicateCmd())
+	rootCmd.AddCommand(editCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(importCmd())
+	rootCmd.AddCommand(shareCmd())
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(profileCmd())
+	rootCmd.AddCommand(historyCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// configWarned keeps loadConfig from repeating the same warnings when a
+// command loads the config more than once.
+var configWarned bool
+
+func loadConfig() config.Config {
+	cfg := config.LoadProfile(profileFlag)
+	if !configWarned {
+		for _, err := range cfg.Warnings() {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		configWarned = true
+	}
+	return cfg
+}
+
 func allLayouts(cfg config.Config) []layout.Layout {
 	layouts := layout.Presets()
 	layouts = append(layouts, cfg.ToLayouts()...)
-	return layouts
+	return cfg.OrderLayouts(layouts)
 }
 
 func visibleLayouts(cfg config.Config) []layout.Layout {
@@ -58,57 +106,331 @@ func visibleLayouts(cfg config.Config) []layout.Layout {
 	return visible
 }
 
+func loadBindings(cfg config.Config) (map[string]engine.KeyCombo, error) {
+	path := cfg.Settings.GhosttyConfigPath
+	if path == "" {
+		path = engine.GhosttyConfigPath()
+	}
+	return engine.ParseGhosttyKeybindings(path)
+}
+
+// applyTheme sets the picker theme from the [theme] config section.
+// NO_COLOR turns colours off whatever the config says.
+func applyTheme(cfg config.Config) error {
+	var theme tui.Theme
+	switch cfg.Theme.Name {
+	case "", "dark":
+		theme = tui.DarkTheme()
+	case "light":
+		theme = tui.LightTheme()
+	case "ghostty":
+		path := cfg.Settings.GhosttyConfigPath
+		if path == "" {
+			path = engine.GhosttyConfigPath()
+		}
+		colors, err := engine.ParseGhosttyColors(path)
+		if err != nil {
+			return fmt.Errorf("failed to read Ghostty colours: %w", err)
+		}
+		theme = tui.GhosttyTheme(colors)
+	default:
+		return fmt.Errorf("unknown theme '%s' — use dark, light or ghostty", cfg.Theme.Name)
+	}
+
+	for _, c := range []struct {
+		value string
+		dst   *lipgloss.TerminalColor
+	}{
+		{cfg.Theme.Card, &theme.Card},
+		{cfg.Theme.Selected, &theme.Selected},
+		{cfg.Theme.Preview, &theme.Preview},
+		{cfg.Theme.Header, &theme.Header},
+		{cfg.Theme.Help, &theme.Help},
+	} {
+		if c.value != "" {
+			*c.dst = lipgloss.Color(c.value)
+		}
+	}
+
+	if cfg.Theme.Border != "" {
+		border, ok := tui.BorderByName(cfg.Theme.Border)
+		if !ok {
+			return fmt.Errorf("unknown border '%s' — use rounded, normal, thick, double or hidden", cfg.Theme.Border)
+		}
+		theme.Border = border
+		theme.SelectedBorder = border
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		noColor := tui.NoColorTheme()
+		noColor.Border = theme.Border
+		theme = noColor
+	}
+
+	tui.SetTheme(theme)
+	return nil
+}
+
+// recordApply adds an apply to the usage history. Failing to write the
+// history never fails the apply itself.
+func recordApply(id string, err error) {
+	dir, _ := os.Getwd()
+	e := history.Entry{
+		LayoutID: id,
+		Time:     time.Now(),
+		Dir:      dir,
+		Success:  err == nil,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	_ = history.Record(e)
+}
+
+// saveLayout saves a layout from the builder. A taken ID is only replaced
+// when replace is set; presets are never shadowed.
+func saveLayout(l layout.Layout, replace bool) error {
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	cl := config.FromLayout(l)
+	if _, taken := findLayout(loadConfig(), cl.ID); taken && !replace && !config.IsPreset(cl.ID) {
+		return tui.ErrLayoutExists
+	}
+	if err := cfg.CreateLayout(cl, replace); err != nil {
+		return err
+	}
+	return config.Save(cfg)
+}
+
+// configStore applies picker changes to the user config file. Hidden
+// layouts and ordering go into the active profile when one is in use.
+type configStore struct{}
+
+func (configStore) SaveLayout(l layout.Layout, replace bool) error {
+	return saveLayout(l, replace)
+}
+
+// DeleteLayout refuses to remove a layout that a workspace still uses.
+func (configStore) DeleteLayout(id string) error {
+	if used := loadConfig().WorkspacesUsing(id); len(used) > 0 {
+		return fmt.Errorf("'%s' is used by workspace %s — change its tabs first", id, strings.Join(used, ", "))
+	}
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	if !cfg.RemoveLayout(id) {
+		return fmt.Errorf("'%s' is not defined in %s", id, config.ConfigPath())
+	}
+	return config.Save(cfg)
+}
+
+// RenameLayout also moves the workspaces in the user file to the new ID.
+// It refuses an ID that any config file or preset already uses, and a
+// layout that a system or project workspace picks, since tyle can't edit
+// those files.
+func (configStore) RenameLayout(id, name string) (layout.Layout, error) {
+	merged := loadConfig()
+	if newID := layout.Slugify(name); newID != id {
+		if _, taken := findLayout(merged, newID); taken {
+			return layout.Layout{}, fmt.Errorf("a layout with ID '%s' already exists", newID)
+		}
+	}
+	for _, w := range merged.WorkspacesUsing(id) {
+		if o := merged.WorkspaceOrigin(w); o.Source != config.SourceUser {
+			return layout.Layout{}, fmt.Errorf("'%s' is used by workspace %s in %s — rename it there first", id, w, o.Path)
+		}
+	}
+
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return layout.Layout{}, err
+	}
+	cl, err := cfg.RenameLayout(id, name)
+	if err != nil {
+		return layout.Layout{}, err
+	}
+	if err := config.Save(cfg); err != nil {
+		return layout.Layout{}, err
+	}
+	return config.Config{CustomLayouts: []config.CustomLayout{cl}}.ToLayouts()[0], nil
+}
+
+// SetHidden changes only the user file's hidden_layouts. A layout hidden
+// by a system or project file stays hidden, and that is reported.
+func (configStore) SetHidden(id string, hidden bool) error {
+	merged := loadConfig()
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	profile := merged.ProfileName()
+	cfg.UpdateSettings(profile, func(s *config.Settings) {
+		user := config.Config{Settings: *s}
+		if hidden {
+			// A profile's list replaces the inherited one, so a profile
+			// that has none yet starts from what is hidden now.
+			if profile != "" && !merged.ProfileHides() {
+				user.Settings.HiddenLayouts = append([]string{}, merged.Settings.HiddenLayouts...)
+			}
+			user.HideLayout(id)
+		} else {
+			user.ShowLayout(id)
+		}
+		s.HiddenLayouts = user.Settings.HiddenLayouts
+	})
+	// The top-level list still applies to a profile that has none of its
+	// own, so showing a layout takes it off both.
+	if !hidden && profile != "" {
+		cfg.ShowLayout(id)
+	}
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+
+	if merged = loadConfig(); !hidden && merged.IsHidden(id) {
+		return fmt.Errorf("'%s' is also hidden by %s", id, merged.HiddenOrigin(id).Path)
+	}
+	return nil
+}
+
+// SetOrder stores the shortest layout_order that gives ids, so layouts the
+// user never moved keep following their default position.
+func (configStore) SetOrder(ids []string) error {
+	merged := loadConfig()
+	var base []string
+	for _, l := range append(layout.Presets(), merged.ToLayouts()...) {
+		base = append(base, l.ID)
+	}
+
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	cfg.UpdateSettings(merged.ProfileName(), func(s *config.Settings) {
+		s.LayoutOrder = config.MinimalOrder(ids, base)
+	})
+	return config.Save(cfg)
+}
+
+func layoutSource(o config.Origin) string {
+	switch o.Source {
+	case config.SourceUser, "":
+		return "custom"
+	default:
+		return string(o.Source)
+	}
+}
+
+func executeOptions(cfg config.Config) engine.Options {
+	return engine.Options{
+		DelayMs:      cfg.Settings.DelayBetweenSplitsMs,
+		AutoEqualize: cfg.Settings.AutoEqualize,
+	}
+}
+
 func runTUI(cmd *cobra.Command, args []string) error {
-	cfg := config.Load()
+	cfg := loadConfig()
 
-	layouts := visibleLayouts(cfg)
-	if len(layouts) == 0 {
+	if len(visibleLayouts(cfg)) == 0 {
 		return fmt.Errorf("no visible layouts — run 'tyle show' to unhide layouts")
 	}
+	layouts := allLayouts(cfg)
 
-	model := tui.NewModel(layouts)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	if err := applyTheme(cfg); err != nil {
+		return err
+	}
 
-	finalModel, err := p.Run()
+	bindings, err := loadBindings(cfg)
 	if err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+		return fmt.Errorf("failed to read Ghostty config: %w", err)
 	}
 
-	m := finalModel.(tui.Model)
+	keys, err := pickerKeys(cfg)
+	if err != nil {
+		return err
+	}
 
-	if m.Cancelled() || m.Selected() == nil {
-		return nil
+	sortMode, err := tui.ParseSortMode(cfg.Settings.PickerSort)
+	if err != nil {
+		return fmt.Errorf("invalid picker_sort: %w", err)
 	}
+	entries, _ := history.Load()
 
-	ghosttyConfig := cfg.Settings.GhosttyConfigPath
-	if ghosttyConfig == "" {
-		ghosttyConfig = engine.GhosttyConfigPath()
+	sources := map[string]string{}
+	for _, cl := range cfg.CustomLayouts {
+		sources[cl.ID] = layoutSource(cfg.LayoutOrigin(cl.ID))
 	}
-	bindings, err := engine.ParseGhosttyKeybindings(ghosttyConfig)
+
+	model := tui.NewModel(layouts, tui.Options{
+		MaxColumns: cfg.Settings.PickerColumns,
+		Sources:    sources,
+		Bindings:   bindings,
+		Execute:    executeOptions(cfg),
+		Hidden:     cfg.Settings.HiddenLayouts,
+		Store:      configStore{},
+		Keys:       keys,
+		Sort:       sortMode,
+		Recent:     history.Recent(entries),
+		Counts:     history.Counts(entries),
+		Apply: func(ctx context.Context, l layout.Layout, progress func(engine.Event)) error {
+			err := engine.ExecuteLayoutContext(ctx, l, bindings, executeOptions(cfg), progress)
+			recordApply(l.ID, err)
+			return err
+		},
+	})
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	finalModel, err := p.Run()
 	if err != nil {
-		return fmt.Errorf("failed to read Ghostty config: %w", err)
+		return fmt.Errorf("TUI error: %w", err)
 	}
 
-	fmt.Printf("Applying layout: %s...\n", m.Selected().Name)
-	time.Sleep(200 * time.Millisecond)
+	m := finalModel.(tui.Model)
 
-	if err := engine.ExecuteLayout(*m.Selected(), bindings, cfg.Settings.DelayBetweenSplitsMs); err != nil {
-		return err
+	if m.Cancelled() || m.Selected() == nil {
+		return nil
+	}
+	if !m.Applied() {
+		// The layout types text, which has to reach the shell rather than
+		// the picker, so it runs only now that the picker has quit.
+		err := engine.ExecuteLayout(*m.Selected(), bindings, executeOptions(cfg))
+		recordApply(m.Selected().ID, err)
+		if err != nil {
+			return err
+		}
+	} else if err := m.ApplyErr(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Printf("Cancelled applying %s\n", m.Selected().Name)
+			return nil
+		}
+		return fmt.Errorf("failed to apply %s: %w", m.Selected().Name, err)
 	}
 
-	fmt.Println("Done!")
+	fmt.Printf("Applied %s\n", m.Selected().Name)
 	return nil
 }
 
 func applyCmd() *cobra.Command {
 	var dryRun bool
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "apply [layout-id]",
 		Short: "Apply a layout directly without the picker",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.Load()
+			out, err := output.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("output") && !dryRun {
+				return fmt.Errorf("--output only applies to --dry-run")
+			}
+
+			cfg := loadConfig()
 			layouts := allLayouts(cfg)
 
 			var target *layout.Layout
@@ -123,111 +445,420 @@ func applyCmd() *cobra.Command {
 			}
 
 			if dryRun {
-				fmt.Printf("Layout: %s (%d panes)\n\n", target.Name, target.PaneCount)
-				fmt.Println("Steps:")
-				for i, step := range target.Steps {
-					switch step.Action {
-					case layout.ActionSplit:
-						fmt.Printf("  %d. Split %s\n", i+1, step.Direction)
-					case layout.ActionFocus:
-						fmt.Printf("  %d. Focus %s\n", i+1, step.Direction)
-					case layout.ActionEqualize:
-						fmt.Printf("  %d. Equalize splits\n", i+1)
-					case layout.ActionDelay:
-						fmt.Printf("  %d. Delay %dms\n", i+1, step.DelayMs)
-					}
+				result := planDryRun(cfg, *target)
+				if out == output.Table {
+					printDryRun(result)
+				} else if err := output.Write(os.Stdout, out, result); err != nil {
+					return err
+				}
+				if n := len(result.Problems); n > 0 {
+					return fmt.Errorf("dry run found %d problem(s)", n)
 				}
 				return nil
 			}
 
-			bindings, _ := engine.ParseGhosttyKeybindings(engine.GhosttyConfigPath())
-			return engine.ExecuteLayout(*target, bindings, cfg.Settings.DelayBetweenSplitsMs)
+			bindings, _ := loadBindings(cfg)
+			err = engine.ExecuteLayout(*target, bindings, executeOptions(cfg))
+			recordApply(target.ID, err)
+			return err
 		},
 	}
 
-	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the keystroke sequence without executing")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the keys each step sends and the panes they make, without executing")
+	cmd.Flags().StringVarP(&format, "output", "o", "table", "Dry-run output format: table, json, yaml or toml")
 	return cmd
 }
 
+// dryRunResult is what apply --dry-run reports: each step with the keys it
+// would send, the pane tree the steps build and anything that would stop
+// the apply or make it go wrong.
+type dryRunResult struct {
+	ID       string               `json:"id" toml:"id"`
+	Name     string               `json:"name" toml:"name"`
+	Panes    int                  `json:"panes" toml:"panes"`
+	Steps    []engine.PlannedStep `json:"steps" toml:"steps"`
+	Result   []string             `json:"result" toml:"result"`
+	Focus    string               `json:"focus" toml:"focus"`
+	Problems []string             `json:"problems" toml:"problems"`
+}
+
+func planDryRun(cfg config.Config, l layout.Layout) dryRunResult {
+	result := dryRunResult{ID: l.ID, Name: l.Name, Panes: l.PaneCount, Problems: []string{}}
+	result.Problems = append(result.Problems, layout.Validate(l)...)
+
+	bindings, err := loadBindings(cfg)
+	if err != nil {
+		result.Problems = append(result.Problems, fmt.Sprintf("failed to read Ghostty config: %v", err))
+	}
+
+	opts := executeOptions(cfg)
+	result.Steps = engine.Plan(l, bindings, opts)
+	for i, step := range result.Steps {
+		if step.Missing {
+			result.Problems = append(result.Problems,
+				fmt.Sprintf("step %d: no keybinding for %s — add it to your Ghostty config", i+1, step.Ghostty))
+		}
+		for _, other := range step.Conflicts {
+			result.Problems = append(result.Problems,
+				fmt.Sprintf("step %d: %s is also bound to %s — Ghostty only runs whichever keybind comes last", i+1, step.Symbol, other))
+		}
+	}
+
+	tree, focus := layout.Simulate(engine.ResolveSteps(l, opts))
+	w, h := tree.PreviewSize()
+	result.Result = tree.Render(w, h).Strings()
+	for i, leaf := range tree.Leaves() {
+		if leaf == focus {
+			result.Focus = layout.LeafLabel(leaf, i)
+		}
+	}
+	return result
+}
+
+func printDryRun(r dryRunResult) {
+	fmt.Printf("Layout: %s (%d panes)\n\n", r.Name, r.Panes)
+
+	fmt.Println("Steps:")
+	width := 0
+	for _, step := range r.Steps {
+		width = max(width, len([]rune(step.Step)))
+	}
+	for i, step := range r.Steps {
+		keys := step.Symbol
+		switch {
+		case step.Missing:
+			keys = "(no keybinding)"
+		case step.Presses > 1:
+			keys += fmt.Sprintf(" ×%d", step.Presses)
+		}
+		if keys == "" {
+			fmt.Printf("  %2d. %s\n", i+1, step.Step)
+			continue
+		}
+		pad := strings.Repeat(" ", width-len([]rune(step.Step)))
+		fmt.Printf("  %2d. %s%s  %s\n", i+1, step.Step, pad, keys)
+	}
+
+	fmt.Println()
+	fmt.Println("Result:")
+	for _, line := range r.Result {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("  Focus ends on pane %s\n", r.Focus)
+
+	if len(r.Problems) > 0 {
+		fmt.Println()
+		fmt.Println("Problems:")
+		for _, p := range r.Problems {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+}
+
+// layoutInfo is one layout as tyle list prints it for scripts.
+type layoutInfo struct {
+	ID          string   `json:"id" toml:"id"`
+	Name        string   `json:"name" toml:"name"`
+	Description string   `json:"description,omitempty" toml:"description,omitempty"`
+	Panes       int      `json:"panes" toml:"panes"`
+	Source      string   `json:"source" toml:"source"`
+	Hidden      bool     `json:"hidden" toml:"hidden"`
+	Preview     []string `json:"preview,omitempty" toml:"preview,omitempty"`
+}
+
 func listCmd() *cobra.Command {
 	var showAll bool
+	var format string
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all available layouts",
-		Run: func(cmd *cobra.Command, args []string) {
-			cfg := config.Load()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out, err := output.ParseFormat(format)
+			if err != nil {
+				return err
+			}
+
+			cfg := loadConfig()
 			layouts := allLayouts(cfg)
 
+			infos := []layoutInfo{}
 			for _, l := range layouts {
-				hidden := ""
-				if cfg.IsHidden(l.ID) {
-					if !showAll {
-						continue
+				hidden := cfg.IsHidden(l.ID)
+				if hidden && !showAll {
+					continue
+				}
+
+				if out == output.Table {
+					suffix := ""
+					if hidden {
+						suffix = " (hidden)"
 					}
-					hidden = " (hidden)"
+					fmt.Printf("  %-20s %s (%d panes)%s\n", l.ID, l.Name, l.PaneCount, suffix)
+					continue
+				}
+
+				source := "preset"
+				if cfg.HasLayout(l.ID) {
+					source = layoutSource(cfg.LayoutOrigin(l.ID))
 				}
-				fmt.Printf("  %-20s %s (%d panes)%s\n", l.ID, l.Name, l.PaneCount, hidden)
+				infos = append(infos, layoutInfo{
+					ID:          l.ID,
+					Name:        l.Name,
+					Description: l.Description,
+					Panes:       l.PaneCount,
+					Source:      source,
+					Hidden:      hidden,
+					Preview:     l.Preview,
+				})
 			}
+
+			if out == output.Table {
+				return nil
+			}
+			return output.Write(os.Stdout, out, struct {
+				Layouts []layoutInfo `json:"layouts" toml:"layouts"`
+			}{infos})
 		},
 	}
 
 	cmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show hidden layouts too")
+	cmd.Flags().StringVarP(&format, "output", "o", "table", "Output format: table, json, yaml or toml")
 	return cmd
 }
 
 func addCmd() *cobra.Command {
-	return &cobra.Command{
+	var visual, force bool
+	var name, description, rows, spec, fromFile string
+	var cols int
+
+	cmd := &cobra.Command{
 		Use:   "add",
-		Short: "Create a custom layout interactively",
+		Short: "Create a custom layout",
+		Long: "Create a custom layout. Without flags, tyle asks for the name, columns and rows.\n\n" +
+			"Flags describe the layout in one of three ways:\n" +
+			"  --cols 3 --rows 1,2,1   columns, and rows in each column\n" +
+			"  --spec \"A | B/C\"        panes side by side with |, stacked with /, grouped with ()\n" +
+			"  --from-file x.toml      [[custom_layouts]] tables in config format (- for stdin)",
+		Example: "  tyle add --name \"API Dev\" --cols 3 --rows 1,2,1\n" +
+			"  tyle add --name \"Editor\" --spec \"editor | (server / logs)\"",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			reader := bufio.NewReader(os.Stdin)
-
-			fmt.Print("Layout name: ")
-			name, _ := reader.ReadString('\n')
-			name = strings.TrimSpace(name)
-			if name == "" {
-				return fmt.Errorf("name cannot be empty")
+			if visual {
+				return runBuilder()
 			}
 
-			fmt.Print("Columns: ")
-			colStr, _ := reader.ReadString('\n')
-			colStr = strings.TrimSpace(colStr)
-			numCols, err := strconv.Atoi(colStr)
-			if err != nil || numCols < 1 || numCols > 6 {
-				return fmt.Errorf("columns must be a number between 1 and 6")
+			// --force and inherited flags like --profile don't describe a
+			// layout, so they leave add interactive.
+			flags := cmd.Flags()
+			described := false
+			for _, f := range []string{"name", "cols", "rows", "spec", "from-file", "description"} {
+				described = described || flags.Changed(f)
+			}
+			if !described {
+				return addInteractive(force)
 			}
 
-			rowsPerCol := make([]int, numCols)
-			for i := 0; i < numCols; i++ {
-				fmt.Printf("Column %d rows: ", i+1)
-				rowStr, _ := reader.ReadString('\n')
-				rowStr = strings.TrimSpace(rowStr)
-				rows, err := strconv.Atoi(rowStr)
-				if err != nil || rows < 1 || rows > 6 {
-					return fmt.Errorf("rows must be a number between 1 and 6")
+			if fromFile != "" {
+				if flags.Changed("cols") || flags.Changed("rows") || spec != "" || name != "" {
+					return fmt.Errorf("--from-file can't be combined with --name, --cols, --rows or --spec")
 				}
-				rowsPerCol[i] = rows
+				return addFromFile(fromFile, force)
 			}
 
-			l := layout.GenerateLayout(name, rowsPerCol)
-
-			fmt.Println()
-			for _, line := range l.Preview {
-				fmt.Printf("  %s\n", line)
+			if name == "" {
+				return fmt.Errorf("--name is required")
 			}
-			fmt.Printf("  %d panes\n\n", l.PaneCount)
 
-			cfg := config.Load()
-			cfg.AddLayout(config.FromLayout(l))
-			if err := config.Save(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			var l layout.Layout
+			switch {
+			case spec != "":
+				if flags.Changed("cols") || flags.Changed("rows") {
+					return fmt.Errorf("--spec can't be combined with --cols or --rows")
+				}
+				tree, err := layout.ParseSpec(spec)
+				if err != nil {
+					return err
+				}
+				l = layout.FromTree(name, tree)
+
+			case flags.Changed("cols") || flags.Changed("rows"):
+				rowsPerCol, err := parseRows(cols, rows)
+				if err != nil {
+					return err
+				}
+				l = layout.GenerateLayout(name, rowsPerCol)
+
+			default:
+				return fmt.Errorf("describe the layout with --cols/--rows, --spec or --from-file")
 			}
 
-			fmt.Printf("Saved \"%s\" to %s\n", l.ID, config.ConfigPath())
-			return nil
+			if description != "" {
+				l.Description = description
+			}
+			return addLayouts([]layout.Layout{l}, force)
 		},
 	}
+
+	cmd.Flags().BoolVar(&visual, "visual", false, "Build the layout by splitting panes in a visual editor")
+	cmd.Flags().StringVar(&name, "name", "", "Layout name (its ID is derived from it)")
+	cmd.Flags().StringVar(&description, "description", "", "Layout description")
+	cmd.Flags().IntVar(&cols, "cols", 0, "Number of columns (1-6)")
+	cmd.Flags().StringVar(&rows, "rows", "", "Rows in each column, comma-separated (1-6 each)")
+	cmd.Flags().StringVar(&spec, "spec", "", "Layout notation, e.g. \"A | B/C\"")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "Read [[custom_layouts]] from a TOML file, or - for stdin")
+	cmd.Flags().BoolVar(&force, "force", false, "Replace a custom layout with the same ID")
+	return cmd
+}
+
+func addInteractive(force bool) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Layout name: ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	fmt.Print("Columns: ")
+	colStr, _ := reader.ReadString('\n')
+	colStr = strings.TrimSpace(colStr)
+	numCols, err := strconv.Atoi(colStr)
+	if err != nil || numCols < 1 || numCols > 6 {
+		return fmt.Errorf("columns must be a number between 1 and 6")
+	}
+
+	rowsPerCol := make([]int, numCols)
+	for i := 0; i < numCols; i++ {
+		fmt.Printf("Column %d rows: ", i+1)
+		rowStr, _ := reader.ReadString('\n')
+		rowStr = strings.TrimSpace(rowStr)
+		rows, err := strconv.Atoi(rowStr)
+		if err != nil || rows < 1 || rows > 6 {
+			return fmt.Errorf("rows must be a number between 1 and 6")
+		}
+		rowsPerCol[i] = rows
+	}
+
+	fmt.Println()
+	return addLayouts([]layout.Layout{layout.GenerateLayout(name, rowsPerCol)}, force)
+}
+
+// parseRows combines --cols and --rows. Either can be given alone: --cols
+// alone means one row per column, and --rows alone sets the column count.
+func parseRows(cols int, rows string) ([]int, error) {
+	var rowsPerCol []int
+	if rows != "" {
+		for _, part := range strings.Split(rows, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n < 1 || n > 6 {
+				return nil, fmt.Errorf("rows must be numbers between 1 and 6, got '%s'", part)
+			}
+			rowsPerCol = append(rowsPerCol, n)
+		}
+	}
+
+	if cols == 0 {
+		cols = len(rowsPerCol)
+	}
+	if cols < 1 || cols > 6 {
+		return nil, fmt.Errorf("columns must be a number between 1 and 6")
+	}
+	if rowsPerCol == nil {
+		rowsPerCol = make([]int, cols)
+		for i := range rowsPerCol {
+			rowsPerCol[i] = 1
+		}
+	}
+	if len(rowsPerCol) != cols {
+		return nil, fmt.Errorf("--rows has %d values for %d columns", len(rowsPerCol), cols)
+	}
+	return rowsPerCol, nil
+}
+
+func addFromFile(path string, force bool) error {
+	data, err := readFileOrStdin(path)
+	if err != nil {
+		return err
+	}
+
+	layouts, err := config.DecodePack(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return addLayouts(layouts, force)
+}
+
+// addLayouts saves new custom layouts to the user config, refusing to
+// overwrite existing ones unless force is set. Nothing is saved if any of
+// them is refused.
+func addLayouts(layouts []layout.Layout, force bool) error {
+	cfg, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	for _, l := range layouts {
+		if problems := layout.Validate(l); len(problems) > 0 {
+			return fmt.Errorf("layout '%s' is invalid: %s", l.ID, strings.Join(problems, "; "))
+		}
+		if err := cfg.CreateLayout(config.FromLayout(l), force); err != nil {
+			return err
+		}
+	}
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	for _, l := range layouts {
+		for _, line := range l.Preview {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Printf("  %d panes\n\n", l.PaneCount)
+		fmt.Printf("Saved \"%s\" to %s\n", l.ID, config.ConfigPath())
+	}
+	return nil
+}
+
+// pickerKeys builds the picker's keymap from the [keys] section.
+func pickerKeys(cfg config.Config) (tui.Keymap, error) {
+	overrides := map[string][]string{}
+	for action, keys := range cfg.Keys {
+		overrides[action] = keys
+	}
+	keys, err := tui.ParseKeymap(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [keys] config: %w", err)
+	}
+	return keys, nil
+}
+
+func runBuilder() error {
+	cfg := loadConfig()
+	if err := applyTheme(cfg); err != nil {
+		return err
+	}
+	keys, err := pickerKeys(cfg)
+	if err != nil {
+		return err
+	}
+
+	p := tea.NewProgram(tui.NewBuilder(saveLayout, keys), tea.WithAltScreen())
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+
+	saved := finalModel.(tui.Builder).Saved()
+	if saved == nil {
+		return nil
+	}
+
+	for _, line := range saved.Preview {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Printf("  %d panes\n\n", saved.PaneCount)
+	fmt.Printf("Saved \"%s\" to %s\n", saved.ID, config.ConfigPath())
+	return nil
 }
 
 func hideCmd() *cobra.Command {
@@ -236,7 +867,7 @@ func hideCmd() *cobra.Command {
 		Short: "Hide a layout from the picker",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.Load()
+			cfg := loadConfig()
 
 			found := false
 			for _, l := range allLayouts(cfg) {
@@ -249,8 +880,7 @@ func hideCmd() *cobra.Command {
 				return fmt.Errorf("layout '%s' not found — run 'tyle list --all' to see all layouts", args[0])
 			}
 
-			cfg.HideLayout(args[0])
-			if err := config.Save(cfg); err != nil {
+			if err := (configStore{}).SetHidden(args[0], true); err != nil {
 				return fmt.Errorf("failed to save config: %w", err)
 			}
 
@@ -266,11 +896,8 @@ func showCmd() *cobra.Command {
 		Short: "Unhide a layout in the picker",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.Load()
-
-			cfg.ShowLayout(args[0])
-			if err := config.Save(cfg); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			if err := (configStore{}).SetHidden(args[0], false); err != nil {
+				return err
 			}
 
 			fmt.Printf("Showing \"%s\" in the picker\n", args[0])
@@ -279,9 +906,606 @@ func showCmd() *cobra.Command {
 	}
 }
 
-func initCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "init",
+func rmCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:     "rm [layout-id]",
+		Aliases: []string{"remove"},
+		Short:   "Delete a custom layout",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l, err := editableLayout(loadConfig(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if !yes && !confirm(fmt.Sprintf("Delete \"%s\" (%s)?", l.Name, l.ID)) {
+				fmt.Println("Kept", l.ID)
+				return nil
+			}
+
+			if err := (configStore{}).DeleteLayout(l.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted \"%s\"\n", l.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return cmd
+}
+
+func renameCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rename [layout-id] [new-name]",
+		Short: "Rename a custom layout",
+		Long: "Rename a custom layout. Its ID is derived from the new name, and\n" +
+			"hidden_layouts, layout_order and the workspace tabs that use it are updated\n" +
+			"to match.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l, err := editableLayout(loadConfig(), args[0])
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(args[1])
+			newID := layout.Slugify(name)
+			if newID == "" {
+				return fmt.Errorf("name must contain letters or digits")
+			}
+
+			if !yes && !confirm(fmt.Sprintf("Rename \"%s\" (%s) to \"%s\" (%s)?", l.Name, l.ID, name, newID)) {
+				fmt.Println("Kept", l.ID)
+				return nil
+			}
+
+			renamed, err := (configStore{}).RenameLayout(l.ID, name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Renamed \"%s\" to \"%s\"\n", l.ID, renamed.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return cmd
+}
+
+func duplicateCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:     "duplicate [layout-id] [new-name]",
+		Aliases: []string{"cp"},
+		Short:   "Copy a layout, including presets, to a new custom layout",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig()
+			l, ok := findLayout(cfg, args[0])
+			if !ok {
+				return fmt.Errorf("layout '%s' not found — run 'tyle list --all' to see all layouts", args[0])
+			}
+
+			dup := l
+			dup.Name = strings.TrimSpace(args[1])
+			dup.ID = layout.Slugify(dup.Name)
+			if dup.ID == "" {
+				return fmt.Errorf("name must contain letters or digits")
+			}
+			if _, exists := findLayout(cfg, dup.ID); exists {
+				return fmt.Errorf("a layout with ID '%s' already exists", dup.ID)
+			}
+
+			if !yes && !confirm(fmt.Sprintf("Copy \"%s\" (%s) to \"%s\" (%s)?", l.Name, l.ID, dup.Name, dup.ID)) {
+				fmt.Println("Nothing copied")
+				return nil
+			}
+
+			user, err := config.LoadUser()
+			if err != nil {
+				return err
+			}
+			if err := user.CreateLayout(config.FromLayout(dup), false); err != nil {
+				return err
+			}
+			if err := config.Save(user); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Printf("Copied \"%s\" to \"%s\" in %s\n", l.ID, dup.ID, config.ConfigPath())
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Don't ask for confirmation")
+	return cmd
+}
+
+func editCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit [layout-id]",
+		Short: "Edit a layout in $VISUAL or $EDITOR",
+		Long: "Open a layout as TOML in $VISUAL or $EDITOR and save it back when the\n" +
+			"editor closes. The layout is checked first; if it has problems the file\n" +
+			"reopens with them listed at the top. Empty the file to cancel.\n\n" +
+			"Editing a preset saves your changes as a custom copy.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig()
+			l, ok := findLayout(cfg, args[0])
+			if !ok {
+				return fmt.Errorf("layout '%s' not found — run 'tyle list --all' to see all layouts", args[0])
+			}
+
+			oldID := l.ID
+			header := fmt.Sprintf("# Editing \"%s\" (%s).", l.Name, l.ID)
+			if config.IsPreset(l.ID) && !cfg.HasLayout(l.ID) {
+				header = fmt.Sprintf("# Editing a copy of the \"%s\" preset (%s).", l.Name, l.ID)
+				l.Name += " Copy"
+				l.ID = layout.Slugify(l.Name)
+				oldID = ""
+			} else if _, err := editableLayout(cfg, l.ID); err != nil {
+				return err
+			}
+			header += " Save and close the editor to apply\n" +
+				"# your changes, or empty the file to cancel. Lines starting with # are ignored.\n" +
+				"# The pane count and preview are worked out from the steps when you save.\n\n"
+
+			edited, ok, err := editLayout(cfg, l, oldID, header)
+			if err != nil || !ok {
+				return err
+			}
+
+			user, err := config.LoadUser()
+			if err != nil {
+				return err
+			}
+			if err := user.ReplaceLayout(oldID, config.FromLayout(edited)); err != nil {
+				return err
+			}
+			if err := config.Save(user); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			result := planDryRun(cfg, edited)
+			for _, line := range result.Result {
+				fmt.Printf("  %s\n", line)
+			}
+			fmt.Printf("  %d panes, focus ends on pane %s\n\n", edited.PaneCount, result.Focus)
+			fmt.Printf("Saved \"%s\" to %s\n", edited.ID, config.ConfigPath())
+
+			if bindings, err := loadBindings(cfg); err == nil {
+				if missing := engine.ValidateBindings(edited, bindings); len(missing) > 0 {
+					fmt.Printf("Warning: no Ghostty keybinding for %s\n", strings.Join(missing, ", "))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// editLayout runs the editor on l until the file holds a valid layout or
+// is emptied. It reports false, printing why, if there is nothing to save.
+func editLayout(cfg config.Config, l layout.Layout, oldID, header string) (layout.Layout, bool, error) {
+	cl := config.FromLayout(l)
+	cl.PaneCount = 0
+	cl.Preview = nil
+
+	var buf strings.Builder
+	file := struct {
+		CustomLayouts []config.CustomLayout `toml:"custom_layouts"`
+	}{[]config.CustomLayout{cl}}
+	if err := toml.NewEncoder(&buf).Encode(file); err != nil {
+		return layout.Layout{}, false, err
+	}
+	original := stripLeadingComments(buf.String())
+
+	tmp, err := os.CreateTemp("", "tyle-*.toml")
+	if err != nil {
+		return layout.Layout{}, false, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	body := original
+	var problems []string
+	for {
+		content := header + body
+		if len(problems) > 0 {
+			content = "# This layout can't be saved yet:\n"
+			for _, p := range problems {
+				content += "#   " + p + "\n"
+			}
+			content += "#\n" + header + body
+		}
+		if err := os.WriteFile(tmp.Name(), []byte(content), 0o644); err != nil {
+			return layout.Layout{}, false, err
+		}
+		if err := runEditor(tmp.Name()); err != nil {
+			return layout.Layout{}, false, err
+		}
+
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return layout.Layout{}, false, err
+		}
+		body = stripLeadingComments(string(data))
+
+		if body == "" {
+			fmt.Println("Empty file — nothing saved")
+			return layout.Layout{}, false, nil
+		}
+		if body == original {
+			fmt.Println("No changes")
+			return layout.Layout{}, false, nil
+		}
+
+		var edited layout.Layout
+		edited, problems = parseEditedLayout(cfg, body, oldID)
+		if len(problems) == 0 {
+			return edited, true, nil
+		}
+	}
+}
+
+// parseEditedLayout decodes and checks the layout from an edit file. oldID
+// is the ID the layout is saved over, so keeping it isn't a conflict. The
+// preview, and the pane count unless the file sets one, come from
+// simulating the steps.
+func parseEditedLayout(cfg config.Config, body, oldID string) (layout.Layout, []string) {
+	var file config.Config
+	md, err := toml.Decode(body, &file)
+	if err != nil {
+		return layout.Layout{}, []string{err.Error()}
+	}
+	if len(file.CustomLayouts) != 1 {
+		return layout.Layout{}, []string{"the file must hold exactly one [[custom_layouts]] table"}
+	}
+
+	var problems []string
+	for _, key := range md.Undecoded() {
+		problems = append(problems, fmt.Sprintf("unknown field '%s'", key))
+	}
+
+	l := file.ToLayouts()[0]
+	if l.ID == "" {
+		l.ID = layout.Slugify(l.Name)
+	}
+	if l.PaneCount == 0 {
+		l.PaneCount = layout.CountPanes(l.Steps)
+	}
+	problems = append(problems, layout.Validate(l)...)
+	if len(problems) == 0 {
+		tree, _ := layout.Simulate(engine.ResolveSteps(l, executeOptions(cfg)))
+		l.Preview = tree.Render(tree.PreviewSize()).Strings()
+	}
+	if _, exists := findLayout(cfg, l.ID); exists && l.ID != oldID {
+		problems = append(problems, fmt.Sprintf("a layout with ID '%s' already exists", l.ID))
+	}
+	return l, problems
+}
+
+// stripLeadingComments drops the comment block tyle writes at the top of an
+// edit file, and any blank lines around what is left.
+func stripLeadingComments(s string) string {
+	lines := strings.Split(s, "\n")
+	for len(lines) > 0 {
+		line := strings.TrimSpace(lines[0])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		lines = lines[1:]
+	}
+	body := strings.TrimRight(strings.Join(lines, "\n"), " \t\n")
+	if body == "" {
+		return ""
+	}
+	return body + "\n"
+}
+
+// runEditor opens path in $VISUAL, then $EDITOR, then vi. The variable may
+// hold arguments, e.g. "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
+
+func exportCmd() *cobra.Command {
+	var as string
+
+	cmd := &cobra.Command{
+		Use:   "export [layout-id...]",
+		Short: "Print layouts as a pack to import elsewhere, or as a script",
+		Long: "Print layouts as a TOML pack that 'tyle import' reads on another machine.\n" +
+			"Presets can be exported too; they are imported as custom layouts.\n\n" +
+			"--as prints a single layout as a standalone script instead, applying it\n" +
+			"with your Ghostty keybindings and delays, for people without tyle or\n" +
+			"for other launchers:\n\n" +
+			"  --as applescript   run it with osascript, or open it in Script Editor\n" +
+			"  --as sh            a shell script wrapping the same AppleScript",
+		Example: "  tyle export dev review > pack.toml\n" +
+			"  tyle export dev --as sh > dev.sh && chmod +x dev.sh",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch as {
+			case "toml", engine.ScriptAppleScript, engine.ScriptShell:
+			default:
+				return fmt.Errorf("unknown export format '%s' — use toml, applescript or sh", as)
+			}
+
+			cfg := loadConfig()
+			layouts, err := findLayouts(cfg, args)
+			if err != nil {
+				return err
+			}
+
+			if as == "toml" {
+				data, err := config.EncodePack(layouts)
+				if err != nil {
+					return err
+				}
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+
+			if len(layouts) > 1 {
+				return fmt.Errorf("--as %s exports one layout at a time", as)
+			}
+			bindings, err := loadBindings(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to read Ghostty config: %w", err)
+			}
+			script, err := engine.ExportScript(layouts[0], bindings, executeOptions(cfg), as)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&as, "as", "toml", "Format: toml, applescript or sh")
+	return cmd
+}
+
+func shareCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "share [layout-id...]",
+		Short: "Print a share code for layouts, to paste into 'tyle import --code'",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			layouts, err := findLayouts(loadConfig(), args)
+			if err != nil {
+				return err
+			}
+			code, err := config.ShareCode(layouts)
+			if err != nil {
+				return err
+			}
+			fmt.Println(code)
+			return nil
+		},
+	}
+}
+
+func importCmd() *cobra.Command {
+	var code, tmux, name, onConflict string
+
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Add layouts from a pack file, a share code or tmux",
+		Long: "Add the layouts in a pack written by 'tyle export' (- reads stdin), or in\n" +
+			"a code printed by 'tyle share', to your config.\n\n" +
+			"--tmux converts a tmux layout string, as printed by\n" +
+			"tmux list-windows -F '#{window_layout}', keeping the panes' proportions.\n\n" +
+			"--on-conflict decides what happens to a layout whose ID is already taken:\n" +
+			"  skip        keep the existing layout (the default)\n" +
+			"  rename      import it under a free name, e.g. \"Dev 2\"\n" +
+			"  overwrite   replace your custom layout; presets are never replaced",
+		Example: "  tyle import pack.toml\n" +
+			"  tyle import --code tyle:q1ZKzs8tyM9LzSv...\n" +
+			"  tyle import --tmux 'bb62,159x48,0,0{79x48,0,0,79x48,80,0}' --name \"Side by side\"",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch onConflict {
+			case "skip", "rename", "overwrite":
+			default:
+				return fmt.Errorf("--on-conflict must be skip, rename or overwrite")
+			}
+
+			sources := len(args)
+			if code != "" {
+				sources++
+			}
+			if tmux != "" {
+				sources++
+			}
+			if sources > 1 {
+				return fmt.Errorf("pass only one of a file, --code or --tmux")
+			}
+			if name != "" && tmux == "" {
+				return fmt.Errorf("--name only applies to --tmux")
+			}
+
+			var layouts []layout.Layout
+			var err error
+			switch {
+			case tmux != "":
+				tree, err := layout.ParseTmux(tmux)
+				if err != nil {
+					return err
+				}
+				if name == "" {
+					name = "tmux " + strconv.Itoa(tree.PaneCount()) + " panes"
+				}
+				layouts = []layout.Layout{layout.FromTree(name, tree)}
+			case code != "":
+				layouts, err = config.DecodeShareCode(code)
+			case len(args) == 1:
+				var data []byte
+				if data, err = readFileOrStdin(args[0]); err != nil {
+					return err
+				}
+				if layouts, err = config.DecodePack(data); err != nil {
+					err = fmt.Errorf("failed to parse %s: %w", args[0], err)
+				}
+			default:
+				return fmt.Errorf("pass a pack file, - for stdin, --code or --tmux")
+			}
+			if err != nil {
+				return err
+			}
+			return importLayouts(layouts, onConflict)
+		},
+	}
+
+	cmd.Flags().StringVar(&code, "code", "", "Import from a share code")
+	cmd.Flags().StringVar(&tmux, "tmux", "", "Import a tmux layout string")
+	cmd.Flags().StringVar(&name, "name", "", "Name for the layout imported with --tmux")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", "skip", "When an ID is taken: skip, rename or overwrite")
+	return cmd
+}
+
+// importLayouts adds layouts to the user config, handling IDs that are
+// already taken as onConflict says. Invalid layouts are skipped.
+func importLayouts(layouts []layout.Layout, onConflict string) error {
+	merged := loadConfig()
+	user, err := config.LoadUser()
+	if err != nil {
+		return err
+	}
+	taken := func(id string) bool {
+		_, exists := findLayout(merged, id)
+		return exists || user.HasLayout(id)
+	}
+
+	imported := 0
+	for _, l := range layouts {
+		if problems := layout.Validate(l); len(problems) > 0 {
+			fmt.Printf("Skipped \"%s\": %s\n", l.ID, strings.Join(problems, "; "))
+			continue
+		}
+
+		if taken(l.ID) {
+			switch {
+			case onConflict == "rename":
+				base := l.Name
+				for n := 2; taken(l.ID); n++ {
+					l.Name = fmt.Sprintf("%s %d", base, n)
+					l.ID = layout.Slugify(l.Name)
+				}
+			case onConflict == "overwrite" && config.IsPreset(l.ID) && !user.HasLayout(l.ID):
+				fmt.Printf("Skipped \"%s\": it is a preset — use --on-conflict rename\n", l.ID)
+				continue
+			case onConflict == "overwrite" && !user.HasLayout(l.ID):
+				fmt.Printf("Skipped \"%s\": it is defined in the %s config\n", l.ID, merged.LayoutOrigin(l.ID))
+				continue
+			case onConflict == "skip":
+				fmt.Printf("Skipped \"%s\": a layout with this ID already exists\n", l.ID)
+				continue
+			}
+		}
+
+		user.AddLayout(config.FromLayout(l))
+		fmt.Printf("Imported \"%s\" (%d panes)\n", l.ID, l.PaneCount)
+		imported++
+	}
+
+	if imported == 0 {
+		return nil
+	}
+	if err := config.Save(user); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("\nSaved to %s\n", config.ConfigPath())
+	return nil
+}
+
+// findLayouts looks up layouts by ID, in the order given.
+func findLayouts(cfg config.Config, ids []string) ([]layout.Layout, error) {
+	var layouts []layout.Layout
+	for _, id := range ids {
+		l, ok := findLayout(cfg, id)
+		if !ok {
+			return nil, fmt.Errorf("layout '%s' not found — run 'tyle list --all' to see all layouts", id)
+		}
+		layouts = append(layouts, l)
+	}
+	return layouts, nil
+}
+
+func readFileOrStdin(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+func findLayout(cfg config.Config, id string) (layout.Layout, bool) {
+	for _, l := range allLayouts(cfg) {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return layout.Layout{}, false
+}
+
+// editableLayout returns a layout that rm and rename may change: one defined
+// in the user config, not a preset or one from a system or project file.
+func editableLayout(cfg config.Config, id string) (layout.Layout, error) {
+	l, ok := findLayout(cfg, id)
+	if !ok {
+		return layout.Layout{}, fmt.Errorf("layout '%s' not found — run 'tyle list --all' to see all layouts", id)
+	}
+	if config.IsPreset(id) && !cfg.HasLayout(id) {
+		return layout.Layout{}, fmt.Errorf("'%s' is a preset — duplicate it to get an editable copy, or hide it with 'tyle hide'", id)
+	}
+	if origin := cfg.LayoutOrigin(id); layoutSource(origin) != "custom" {
+		return layout.Layout{}, fmt.Errorf("'%s' is defined in the %s config — edit it there", id, origin)
+	}
+	return l, nil
+}
+
+// confirm asks a yes/no question on stdin. Anything but y or yes, including
+// end of input, is a no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func initCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
 		Short: "Print the Ghostty keybind to add to your config",
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Add this line to your Ghostty config:")
@@ -301,24 +1525,402 @@ func initCmd() *cobra.Command {
 }
 
 func resetCmd() *cobra.Command {
-	return &cobra.Command{
+	var panes int
+	var keepFocused, dryRun bool
+
+	cmd := &cobra.Command{
 		Use:   "reset",
-		Short: "Close all splits in the current tab",
+		Short: "Close the splits around the current pane",
+		Long: "Close splits, keeping the pane reset runs in.\n\n" +
+			"By default it closes the panes that layouts applied from this pane created,\n" +
+			"as recorded in the journal. If splits were made or closed by hand, pass\n" +
+			"--panes with the number of panes in the tab to close all the others: the\n" +
+			"journal can't see those, and closing more panes than the tab has would\n" +
+			"close the tab itself.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			bindings, _ := engine.ParseGhosttyKeybindings(engine.GhosttyConfigPath())
-			combo, ok := bindings["close_surface"]
-			if !ok {
-				return fmt.Errorf("no keybinding found for close_surface")
+			cfg := loadConfig()
+
+			before, after := 0, panes-1
+			if !cmd.Flags().Changed("panes") {
+				pane := engine.CurrentPane()
+				if pane == "" {
+					return fmt.Errorf("can't tell which pane this is — pass --panes with the number of panes in the tab")
+				}
+				var err error
+				before, after, err = engine.JournalledPanes(pane)
+				if err != nil {
+					return fmt.Errorf("failed to read the apply journal: %w", err)
+				}
+				if before+after == 0 {
+					return fmt.Errorf("no layouts applied from this pane in the journal — pass --panes with the number of panes in the tab")
+				}
+			} else if panes < 1 {
+				return fmt.Errorf("--panes must be at least 1")
 			}
+			count := before + after
 
-			fmt.Println("Closing splits...")
-			for i := 0; i < 10; i++ {
-				if err := engine.SendKeystroke(combo); err != nil {
-					break
+			if dryRun {
+				bindings, _ := loadBindings(cfg)
+				fmt.Printf("Would close %d panes:\n", count)
+				for _, action := range engine.CloseAround(before, after) {
+					keys := "(no keybinding)"
+					if combo, ok := bindings[action]; ok {
+						keys = combo.String()
+					}
+					fmt.Printf("  %-22s %s\n", action, keys)
+				}
+				return nil
+			}
+
+			if count == 0 {
+				fmt.Println("Nothing to close")
+				return nil
+			}
+
+			bindings, err := loadBindings(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to read Ghostty config: %w", err)
+			}
+
+			fmt.Printf("Closing %d splits...\n", count)
+			return engine.Reset(context.Background(), before, after, bindings, executeOptions(cfg))
+		},
+	}
+
+	cmd.Flags().IntVar(&panes, "panes", 0, "Number of panes open in the tab (overrides the journal)")
+	cmd.Flags().BoolVar(&keepFocused, "keep-focused", false, "Keep the focused pane")
+	_ = cmd.Flags().MarkDeprecated("keep-focused", "reset always keeps the pane it runs in")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the keystrokes without sending them")
+	return cmd
+}
+
+func upCmd() *cobra.Command {
+	var here, dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "up [workspace]",
+		Short: "Open a workspace's tabs and layouts",
+		Long: "Open every tab of a workspace, apply its layout and run its commands.\n\n" +
+			"Without arguments, lists the workspaces defined in the config.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig()
+
+			if len(args) == 0 {
+				if len(cfg.Workspaces) == 0 {
+					fmt.Println("No workspaces defined — add [[workspaces]] to your config")
+					return nil
+				}
+				for _, w := range cfg.Workspaces {
+					fmt.Printf("  %-20s %s (%d tabs)\n", w.ID, w.Name, len(w.Tabs))
+				}
+				return nil
+			}
+
+			ws, err := cfg.ResolveWorkspace(args[0], allLayouts(cfg))
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				fmt.Printf("Workspace: %s (%d tabs, %d panes)\n", ws.Name, len(ws.Tabs), ws.Panes())
+				for i, tab := range ws.Tabs {
+					where := "new tab"
+					if tab.Window {
+						where = "new window"
+					} else if i == 0 && here {
+						where = "current tab"
+					}
+					fmt.Printf("\n%d. %s — %s, %s\n", i+1, tabTitle(tab, i), tab.Layout.Name, where)
+					for j, step := range engine.ResolveSteps(tab.Layout, executeOptions(cfg)) {
+						fmt.Printf("     %d. %s\n", j+1, step)
+					}
+					for j, command := range tab.Commands {
+						if command != "" {
+							fmt.Printf("     pane %d: %s\n", j+1, command)
+						}
+					}
 				}
-				time.Sleep(150 * time.Millisecond)
+				return nil
+			}
+
+			bindings, err := loadBindings(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to read Ghostty config: %w", err)
 			}
+
+			fmt.Printf("Bringing up %s...\n", ws.Name)
+			err = engine.ExecuteWorkspace(context.Background(), ws, bindings, executeOptions(cfg), here, nil)
+			for _, tab := range ws.Tabs {
+				recordApply(tab.Layout.ID, err)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&here, "here", false, "Build the first tab in the current tab")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the tabs, steps and commands without executing")
+	return cmd
+}
+
+func tabTitle(tab layout.Tab, i int) string {
+	if tab.Title != "" {
+		return tab.Title
+	}
+	return fmt.Sprintf("Tab %d", i+1)
+}
+
+func undoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo",
+		Short: "Close the panes created by the last applied layout",
+		Long: "Close the panes created by the last layout applied from this pane.\n\n" +
+			"Run it from the pane the layout was applied from. Each apply is journalled\n" +
+			"with the pane it was made from, so running undo again reverts the apply\n" +
+			"before that from the same pane. Tabs opened by 'tyle up' aren't tracked.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig()
+			bindings, err := loadBindings(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to read Ghostty config: %w", err)
+			}
+
+			entry, err := engine.Undo(context.Background(), bindings, executeOptions(cfg))
+			if errors.Is(err, engine.ErrNothingToUndo) {
+				fmt.Println("Nothing to undo from this pane")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Closed %d panes from %s (applied %s)\n",
+				entry.Panes, entry.LayoutID, entry.Time.Local().Format("15:04"))
 			return nil
 		},
 	}
 }
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the merged configuration",
+	}
+
+	var showOrigin bool
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Print the configuration after merging all sources",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := loadConfig()
+
+			if !showOrigin {
+				return toml.NewEncoder(os.Stdout).Encode(cfg)
+			}
+
+			if name := cfg.ProfileName(); name != "" {
+				fmt.Printf("Profile: %s\n\n", name)
+			}
+
+			fmt.Println("Settings:")
+			for _, s := range cfg.SettingList() {
+				fmt.Printf("  %-26s %-30s %s\n", s.Key, fmt.Sprint(s.Value), s.Origin)
+			}
+
+			fmt.Println()
+			fmt.Println("Layouts:")
+			for _, l := range layout.Presets() {
+				fmt.Printf("  %-26s %s\n", l.ID, "preset")
+			}
+			for _, cl := range cfg.CustomLayouts {
+				fmt.Printf("  %-26s %s\n", cl.ID, cfg.LayoutOrigin(cl.ID))
+			}
+			return nil
+		},
+	}
+	show.Flags().BoolVar(&showOrigin, "origin", false, "Show which file each setting and layout came from")
+
+	var dryRun bool
+	var file string
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade a config file to the current schema version",
+		Long: "Upgrade a config file to the current schema version.\n\n" +
+			"Older files are already upgraded in memory every time tyle loads them; this\n" +
+			"rewrites the file on disk. Comments and keys tyle doesn't recognise are not\n" +
+			"preserved, but the original is kept as a .bak file next to it.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				file = config.ConfigPath()
+			}
+
+			result, err := config.MigrateFile(file, !dryRun)
+			if err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", file, err)
+			}
+			if !result.Changed() {
+				fmt.Printf("%s is already at version %d\n", file, config.CurrentVersion)
+				return nil
+			}
+
+			for _, m := range result.Applied {
+				fmt.Printf("  v%d → v%d: %s\n", m.From, m.From+1, m.Summary)
+			}
+			fmt.Println()
+			fmt.Printf("--- %s\n+++ %s (migrated)\n", file, file)
+			fmt.Print(lineDiff(string(result.Before), string(result.After)))
+
+			if dryRun {
+				return nil
+			}
+			fmt.Println()
+			fmt.Printf("Migrated %s to version %d (backup at %s)\n", file, config.CurrentVersion, result.Backup)
+			fmt.Println("Comments and unrecognised keys were not carried over — copy any you need from the backup.")
+			return nil
+		},
+	}
+	migrate.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without writing them")
+	migrate.Flags().StringVar(&file, "file", "", "Config file to migrate (default: user config)")
+
+	cmd.AddCommand(show)
+	cmd.AddCommand(migrate)
+	return cmd
+}
+
+func lineDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, " %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "-%s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+%s\n", b[j])
+			j++
+		}
+	}
+	return out.String()
+}
+
+func profileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "List and switch config profiles",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List available profiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := loadConfig()
+			for _, name := range cfg.ProfileNames() {
+				marker := " "
+				if name == cfg.ProfileName() {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\n", marker, name)
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use [profile]",
+		Short: "Make a profile the default",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !loadConfig().HasProfile(args[0]) {
+				return fmt.Errorf("profile '%s' not found — run 'tyle profile list' to see available profiles", args[0])
+			}
+
+			cfg, err := config.LoadUser()
+			if err != nil {
+				return err
+			}
+			cfg.ActiveProfile = args[0]
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			fmt.Printf("Using profile \"%s\"\n", args[0])
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Stop using a default profile",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadUser()
+			if err != nil {
+				return err
+			}
+			cfg.ActiveProfile = ""
+			if err := config.Save(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			fmt.Println("No profile in use")
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func historyCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List recently applied layouts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := history.Load()
+			if err != nil {
+				return fmt.Errorf("failed to read history: %w", err)
+			}
+			if len(entries) == 0 {
+				fmt.Println("No layouts applied yet")
+				return nil
+			}
+
+			home, _ := os.UserHomeDir()
+			for _, e := range history.Newest(entries, limit) {
+				status := "ok"
+				if !e.Success {
+					status = "failed: " + e.Error
+				}
+				dir := e.Dir
+				if home != "" && strings.HasPrefix(dir, home) {
+					dir = "~" + strings.TrimPrefix(dir, home)
+				}
+				fmt.Printf("  %s  %-20s %-30s %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.LayoutID, dir, status)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of entries to show (0 for all)")
+	return cmd
+}